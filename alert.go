@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/alexeykiselev/waves-block-complexity/pkg/complexity"
+	"github.com/pkg/errors"
+)
+
+const (
+	alertTopDApps      = 5                // Number of dApps with the highest complexity listed in an alert
+	alertNotifyTimeout = 10 * time.Second // Timeout of delivering an alert
+)
+
+// notifier delivers alerts and digests as text messages, emphasized parts of messages are enclosed in asterisks.
+type notifier interface {
+	send(text string) error
+	// name returns the name of the notification channel used in diagnostic messages.
+	name() string
+}
+
+// alert describes the block which complexity or utilization crossed the thresholds.
+type alert struct {
+	block   complexity.BlockComplexity
+	reasons []string                    // Thresholds crossed by the block
+	dApps   []complexity.DAppComplexity // dApps with the highest complexity
+}
+
+// text formats the alert as a message.
+func (a alert) text() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "*Block %s at height %d*: %s\n", a.block.ID.String(), a.block.Height, strings.Join(a.reasons, ", "))
+	fmt.Fprintf(&sb, "Complexity: %d", a.block.Complexity)
+	if a.block.Limit > 0 {
+		fmt.Fprintf(&sb, " of %d (%.2f%%)", a.block.Limit, a.block.Utilization)
+	}
+	fmt.Fprintf(&sb, ", %d transactions", len(a.block.Transactions))
+	if len(a.dApps) > 0 {
+		sb.WriteString("\nTop dApps:")
+		for _, d := range a.dApps {
+			fmt.Fprintf(&sb, "\n%s\t%d\t%d", d.DApp, d.Transactions, d.Complexity)
+		}
+	}
+	return sb.String()
+}
+
+// alertPrinter passes results to the underlying printer and sends an alert to the notifiers about every block
+// which complexity or utilization exceeds the threshold. Failures to deliver alerts are logged and do not stop
+// processing.
+type alertPrinter struct {
+	printer
+	complexity  int     // No threshold of complexity if zero
+	utilization float64 // No threshold of utilization if zero
+	notifiers   []notifier
+}
+
+func (p *alertPrinter) block(b complexity.BlockComplexity) error {
+	p.check(b)
+	return p.printer.block(b)
+}
+
+func (p *alertPrinter) summary(b complexity.BlockComplexity) error {
+	p.check(b)
+	return p.printer.summary(b)
+}
+
+func (p *alertPrinter) check(b complexity.BlockComplexity) {
+	var reasons []string
+	if p.complexity > 0 && b.Complexity > p.complexity {
+		reasons = append(reasons, fmt.Sprintf("complexity exceeds %d", p.complexity))
+	}
+	if p.utilization > 0 && b.Utilization > p.utilization {
+		reasons = append(reasons, fmt.Sprintf("utilization exceeds %g%%", p.utilization))
+	}
+	if len(reasons) == 0 {
+		return
+	}
+	notify(p.notifiers, alert{block: b, reasons: reasons})
+}
+
+// notify sends the alert to the notifiers, listing the dApps with the highest complexity of the block.
+func notify(notifiers []notifier, a alert) {
+	a.dApps = a.block.DApps()
+	if len(a.dApps) > alertTopDApps {
+		a.dApps = a.dApps[:alertTopDApps]
+	}
+	for _, n := range notifiers {
+		if err := n.send(a.text()); err != nil {
+			slog.Warn("Failed to send alert", "channel", n.name(), "height", a.block.Height, "error", err)
+		}
+	}
+}
+
+// newAlertClient returns the HTTP client for delivering alerts to external services.
+func newAlertClient() *http.Client {
+	return &http.Client{Timeout: alertNotifyTimeout}
+}
+
+// postJSON posts the value as JSON to the URL expecting the response with status OK. The URL is omitted from
+// errors, because URLs of webhooks and bot APIs contain secrets.
+func postJSON(cl *http.Client, u string, v interface{}) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	resp, err := cl.Post(u, "application/json", bytes.NewReader(body))
+	if err != nil {
+		var ue *url.Error
+		if errors.As(err, &ue) {
+			return ue.Err
+		}
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return errors.Errorf("unexpected status %d: %s", resp.StatusCode, bytes.TrimSpace(msg))
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+
+	"github.com/alexeykiselev/waves-block-complexity/pkg/complexity"
+)
+
+const (
+	defaultAnomalyAlpha = 0.1
+	anomalyWarmup       = 10 // Number of blocks the baseline is built from before anomalies are detected
+)
+
+// baseline is the exponentially weighted moving average and standard deviation of complexities of blocks.
+type baseline struct {
+	alpha    float64 // Weight of the newest value
+	mean     float64
+	variance float64
+	n        int // Number of added values
+}
+
+// add adds the value to the baseline.
+func (b *baseline) add(x float64) {
+	b.n++
+	if b.n == 1 {
+		b.mean = x
+		return
+	}
+	d := x - b.mean
+	inc := b.alpha * d
+	b.mean += inc
+	b.variance = (1 - b.alpha) * (b.variance + d*inc)
+}
+
+func (b *baseline) stddev() float64 {
+	return math.Sqrt(b.variance)
+}
+
+// anomalyPrinter passes results to the underlying printer and flags the blocks which complexity exceeds the baseline
+// of the preceding blocks by more than the given number of standard deviations. Every anomaly is logged as a warning
+// with "ANOMALY" message and sent as an alert to the notifiers, if any.
+type anomalyPrinter struct {
+	printer
+	sigmas    float64
+	base      baseline
+	notifiers []notifier
+}
+
+func (p *anomalyPrinter) block(b complexity.BlockComplexity) error {
+	p.check(b)
+	return p.printer.block(b)
+}
+
+func (p *anomalyPrinter) summary(b complexity.BlockComplexity) error {
+	p.check(b)
+	return p.printer.summary(b)
+}
+
+func (p *anomalyPrinter) check(b complexity.BlockComplexity) {
+	defer p.base.add(float64(b.Complexity))
+	sd := p.base.stddev()
+	if p.base.n < anomalyWarmup || sd == 0 {
+		return
+	}
+	dev := (float64(b.Complexity) - p.base.mean) / sd
+	if dev <= p.sigmas {
+		return
+	}
+	slog.Warn("ANOMALY", "id", b.ID.String(), "height", b.Height, "complexity", b.Complexity,
+		"baseline", math.Round(p.base.mean), "stddev", math.Round(sd), "sigmas", math.Round(dev*10)/10)
+	a := alert{block: b, reasons: []string{fmt.Sprintf("ANOMALY, complexity is %.1f sigmas above baseline %.0f", dev, p.base.mean)}}
+	notify(p.notifiers, a)
+}
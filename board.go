@@ -0,0 +1,189 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alexeykiselev/waves-block-complexity/pkg/complexity"
+)
+
+const (
+	defaultDashboardBlocks = 60
+	dashboardDApps         = 10
+	dashboardPeriod        = time.Hour
+	dashboardRefresh       = time.Second
+	dashboardBarWidth      = 50
+)
+
+var sparks = []rune("▁▂▃▄▅▆▇█")
+
+// boardPrinter renders the live dashboard of followed blocks in the terminal. The dashboard is redrawn on every
+// block and every second in between, to keep the age of the head current. Blocks of the last hour by time of the
+// head are kept for the leaderboard of dApps, at least the blocks of the sparkline are kept.
+type boardPrinter struct {
+	w      io.Writer
+	c      palette
+	n      int // Number of blocks of the sparkline
+	mu     sync.Mutex
+	blocks []complexity.BlockComplexity
+	stop   chan struct{}
+	once   sync.Once
+}
+
+func newBoardPrinter(w io.Writer, n int, c palette) *boardPrinter {
+	p := &boardPrinter{w: w, c: c, n: n, stop: make(chan struct{})}
+	go p.refresh()
+	return p
+}
+
+func (p *boardPrinter) refresh() {
+	ticker := time.NewTicker(dashboardRefresh)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+			p.mu.Lock()
+			_ = p.draw()
+			p.mu.Unlock()
+		}
+	}
+}
+
+func (p *boardPrinter) block(b complexity.BlockComplexity) error {
+	return p.summary(b)
+}
+
+func (p *boardPrinter) summary(b complexity.BlockComplexity) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.blocks = append(p.blocks, b)
+	since := periodStart(b.Timestamp)
+	for len(p.blocks) > p.n && p.blocks[0].Timestamp < since {
+		p.blocks = p.blocks[1:]
+	}
+	return p.draw()
+}
+
+func (p *boardPrinter) stats(complexity.RangeStats) error {
+	return nil
+}
+
+func (p *boardPrinter) flush() error {
+	p.once.Do(func() { close(p.stop) })
+	return nil
+}
+
+func (p *boardPrinter) streaming() bool {
+	return true
+}
+
+// draw clears the terminal and writes the dashboard, the caller holds the lock.
+func (p *boardPrinter) draw() error {
+	sb := new(strings.Builder)
+	sb.WriteString(ansiClear)
+	sb.WriteString(p.c.bold("Waves Block Complexity") + "\t" + time.Now().UTC().Format(time.DateTime) + " UTC\n\n")
+	if len(p.blocks) == 0 {
+		sb.WriteString("Waiting for blocks...\n")
+		_, err := io.WriteString(p.w, sb.String())
+		return err
+	}
+	head := p.blocks[len(p.blocks)-1]
+	age := max(time.Since(time.UnixMilli(int64(head.Timestamp))), 0).Round(time.Second) // Clocks of generators may be ahead
+	fmt.Fprintf(sb, "Head: %d, block %s forged by %s %s ago\n", head.Height, head.ID.String(), head.Generator.String(), age)
+	fmt.Fprintf(sb, "Complexity: %d of %d, %d transactions, fees %s WAVES\n", head.Complexity, head.Limit, len(head.Transactions), waves(head.Fees))
+	fmt.Fprintf(sb, "Utilization: %s %s\n\n", utilizationBar(head.Utilization, dashboardBarWidth), p.c.utilization(head.Utilization))
+	recent := p.blocks[max(len(p.blocks)-p.n, 0):]
+	fmt.Fprintf(sb, "Last %d blocks: %s max %d\n\n", len(recent), sparkline(recent), maxComplexity(recent))
+	since := periodStart(head.Timestamp)
+	dApps, total, blocks := hourDApps(p.blocks, since)
+	sb.WriteString(p.c.bold(fmt.Sprintf("Top dApps over the last hour (%d blocks):", blocks)) + "\n")
+	if len(dApps) == 0 {
+		sb.WriteString("No invocations\n")
+	}
+	for i, d := range dApps {
+		if i == dashboardDApps {
+			break
+		}
+		fmt.Fprintf(sb, "%-36s\t%d txs\t%d\t%.2f%%\n", d.DApp, d.Transactions, d.Complexity, 100*ratio(d.Complexity, float64(total)))
+	}
+	_, err := io.WriteString(p.w, sb.String())
+	return err
+}
+
+// periodStart returns the timestamp the period of the dashboard ending at the timestamp starts from, zero if the
+// period starts before the epoch.
+func periodStart(ts uint64) uint64 {
+	if period := uint64(dashboardPeriod.Milliseconds()); ts > period {
+		return ts - period
+	}
+	return 0
+}
+
+// hourDApps aggregates complexities of the blocks created since the timestamp by the called dApp, the total
+// complexity and the number of the blocks are also returned.
+func hourDApps(blocks []complexity.BlockComplexity, since uint64) ([]complexity.DAppComplexity, int, int) {
+	m := make(map[string]*complexity.DAppComplexity)
+	total, n := 0, 0
+	for _, b := range blocks {
+		if b.Timestamp < since {
+			continue
+		}
+		n++
+		total += b.Complexity
+		for _, d := range b.DApps() {
+			dc, ok := m[d.DApp]
+			if !ok {
+				dc = &complexity.DAppComplexity{DApp: d.DApp}
+				m[d.DApp] = dc
+			}
+			dc.Transactions += d.Transactions
+			dc.Complexity += d.Complexity
+		}
+	}
+	r := make([]complexity.DAppComplexity, 0, len(m))
+	for _, dc := range m {
+		r = append(r, *dc)
+	}
+	sort.Slice(r, func(i, j int) bool {
+		if r[i].Complexity != r[j].Complexity {
+			return r[i].Complexity > r[j].Complexity
+		}
+		return r[i].DApp < r[j].DApp
+	})
+	return r, total, n
+}
+
+// sparkline draws complexities of the blocks scaled to the maximum one.
+func sparkline(blocks []complexity.BlockComplexity) string {
+	top := maxComplexity(blocks)
+	r := make([]rune, len(blocks))
+	for i, b := range blocks {
+		level := 0
+		if top > 0 {
+			level = b.Complexity * (len(sparks) - 1) / top
+		}
+		r[i] = sparks[level]
+	}
+	return string(r)
+}
+
+func maxComplexity(blocks []complexity.BlockComplexity) int {
+	top := 0
+	for _, b := range blocks {
+		top = max(top, b.Complexity)
+	}
+	return top
+}
+
+// utilizationBar draws the utilization percentage as a bar of the given width.
+func utilizationBar(u float64, width int) string {
+	filled := int(math.Round(math.Min(math.Max(u, 0), 100) * float64(width) / 100))
+	return "[" + strings.Repeat("█", filled) + strings.Repeat("░", width-filled) + "]"
+}
@@ -0,0 +1,340 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/alexeykiselev/waves-block-complexity/pkg/complexity"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/pkg/errors"
+)
+
+const defaultBrowseBlocks = 20
+
+func init() {
+	commands = append(commands, command{
+		name:        "browse",
+		description: "Browse complexity of recent blocks and their transactions in the terminal",
+		flags: func(fs *flag.FlagSet, o *options) {
+			fs.IntVar(&o.recent, "blocks", defaultBrowseBlocks, "Number of the most recent blocks listed. Default value is 20")
+			fs.BoolVar(&o.noColor, "no-color", false, "Do not color the browser, it's colored only if NO_COLOR is not set, default value is false")
+			fs.IntVar(&o.highlight, "highlight", defaultHighlight, "Complexity of a transaction highlighted in colored output, no highlighting if zero. Default value is 10000")
+		},
+		run: runBrowse,
+	})
+}
+
+func runBrowse(ctx context.Context, o *options, args []string) error {
+	if err := noArguments(args); err != nil {
+		return err
+	}
+	if o.recent < 1 {
+		err := errors.New("at least one block must be listed")
+		slog.Error("Invalid parameters", "blocks", o.recent, "error", err)
+		return err
+	}
+	if !terminal(os.Stdout) {
+		err := errors.New("stdout is not a terminal")
+		slog.Error("Failed to start browser", "error", err)
+		return err
+	}
+	p, closer, err := o.processor(ctx, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer closer()
+	// Messages logged while the browser occupies the terminal would break its screen, errors are shown by the browser
+	l := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	defer slog.SetDefault(l)
+	_, off := os.LookupEnv("NO_COLOR")
+	b := newBrowser(ctx, p.an, o.recent, o.highlight, palette(!o.noColor && !off))
+	if _, err := tea.NewProgram(b, tea.WithAltScreen(), tea.WithContext(ctx)).Run(); err != nil {
+		slog.SetDefault(l)
+		slog.Error("Failed to run browser", "error", err)
+		return err
+	}
+	return nil
+}
+
+// heightMsg reports the height of the blockchain the list of blocks starts from.
+type heightMsg struct {
+	height uint64
+	err    error
+}
+
+// blockMsg reports the analyzed block at the height, the block is opened if requested by navigation.
+type blockMsg struct {
+	height uint64
+	bc     *complexity.BlockComplexity
+	open   bool
+	err    error
+}
+
+// browser is the terminal UI listing the recent blocks and the transactions of the opened block sorted by complexity.
+type browser struct {
+	ctx       context.Context
+	an        *complexity.Analyzer
+	count     int // Number of listed blocks
+	highlight int
+	c         palette
+	top       uint64                                 // Height of the first listed block, zero until it's known
+	blocks    map[uint64]*complexity.BlockComplexity // Analyzed blocks by height
+	cursor    int                                    // Selected row of the list of blocks
+	opened    *complexity.BlockComplexity            // Block which transactions are shown, the list is shown if nil
+	txs       []complexity.Complexity                // Transactions of the opened block sorted by complexity descending
+	row       int                                    // Selected transaction of the opened block
+	loading   uint64                                 // Height of the block being analyzed, zero if none
+	pending   uint64                                 // Height of the block to open once the analyzed one is done
+	rows      int                                    // Height of the terminal
+	err       error
+}
+
+func newBrowser(ctx context.Context, an *complexity.Analyzer, count, highlight int, c palette) *browser {
+	return &browser{ctx: ctx, an: an, count: count, highlight: highlight, c: c, blocks: make(map[uint64]*complexity.BlockComplexity), rows: 24}
+}
+
+func (b *browser) Init() tea.Cmd {
+	return b.height()
+}
+
+func (b *browser) height() tea.Cmd {
+	return func() tea.Msg {
+		h, err := b.an.Height(b.ctx)
+		return heightMsg{height: h, err: err}
+	}
+}
+
+// analyze returns the command analyzing the block at the height, or nil if another block is being analyzed.
+func (b *browser) analyze(height uint64, open bool) tea.Cmd {
+	if b.loading != 0 {
+		return nil
+	}
+	b.loading = height
+	return func() tea.Msg {
+		bc, err := b.an.BlockAt(b.ctx, height)
+		return blockMsg{height: height, bc: bc, open: open, err: err}
+	}
+}
+
+// next returns the command analyzing the first listed block which is not analyzed yet, nil if all are analyzed.
+func (b *browser) next() tea.Cmd {
+	for i := 0; i < b.listed(); i++ {
+		if h := b.top - uint64(i); b.blocks[h] == nil {
+			return b.analyze(h, false)
+		}
+	}
+	return nil
+}
+
+// listed returns the number of listed blocks, which is less than requested at the start of the blockchain.
+func (b *browser) listed() int {
+	if uint64(b.count) > b.top {
+		return int(b.top)
+	}
+	return b.count
+}
+
+func (b *browser) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch m := msg.(type) {
+	case tea.WindowSizeMsg:
+		b.rows = m.Height
+	case heightMsg:
+		if m.err != nil {
+			b.err = errors.Wrap(m.err, "failed to get height")
+			return b, nil
+		}
+		// The last blocks may have grown with microblocks since they were analyzed
+		delete(b.blocks, b.top)
+		delete(b.blocks, m.height)
+		b.top, b.err = m.height, nil
+		return b, b.next()
+	case blockMsg:
+		b.loading = 0
+		if m.err != nil {
+			b.err = errors.Wrapf(m.err, "failed to analyze block at height %d", m.height)
+			return b, nil
+		}
+		b.blocks[m.height], b.err = m.bc, nil
+		if m.open {
+			b.open(m.bc)
+		}
+		if h := b.pending; h != 0 {
+			b.pending = 0
+			return b, b.jump(h)
+		}
+		return b, b.next()
+	case tea.KeyMsg:
+		if b.opened != nil {
+			return b, b.blockKey(m.String())
+		}
+		return b, b.listKey(m.String())
+	}
+	return b, nil
+}
+
+func (b *browser) listKey(key string) tea.Cmd {
+	switch key {
+	case "q", "ctrl+c":
+		return tea.Quit
+	case "up", "k":
+		b.cursor = max(b.cursor-1, 0)
+	case "down", "j":
+		b.cursor = max(min(b.cursor+1, b.listed()-1), 0)
+	case "home", "g":
+		b.cursor = 0
+	case "end", "G":
+		b.cursor = max(b.listed()-1, 0)
+	case "enter", "right", "l":
+		if b.top == 0 {
+			return nil
+		}
+		return b.jump(b.top - uint64(b.cursor))
+	case "r":
+		return b.height()
+	}
+	return nil
+}
+
+func (b *browser) blockKey(key string) tea.Cmd {
+	switch key {
+	case "q", "ctrl+c":
+		return tea.Quit
+	case "esc", "backspace":
+		// The list is scrolled to the opened block if it's listed
+		if d := int64(b.top) - int64(b.opened.Height); d >= 0 && d < int64(b.listed()) {
+			b.cursor = int(d)
+		}
+		b.opened = nil
+	case "up", "k":
+		b.row = max(b.row-1, 0)
+	case "down", "j":
+		b.row = max(min(b.row+1, len(b.txs)-1), 0)
+	case "pgup":
+		b.row = max(b.row-b.page(), 0)
+	case "pgdown":
+		b.row = max(min(b.row+b.page(), len(b.txs)-1), 0)
+	case "left", "h", "p":
+		if b.opened.Height > 1 {
+			return b.jump(b.opened.Height - 1)
+		}
+	case "right", "l", "n":
+		return b.jump(b.opened.Height + 1)
+	}
+	return nil
+}
+
+// jump opens the block at the height, analyzing it first unless it's analyzed already. The block is opened
+// after the analysis of another block is done.
+func (b *browser) jump(height uint64) tea.Cmd {
+	if bc, ok := b.blocks[height]; ok {
+		b.open(bc)
+		return nil
+	}
+	if b.loading != 0 {
+		b.pending = height
+		return nil
+	}
+	return b.analyze(height, true)
+}
+
+func (b *browser) open(bc *complexity.BlockComplexity) {
+	b.opened, b.row = bc, 0
+	b.txs = append([]complexity.Complexity(nil), bc.Transactions...)
+	sort.SliceStable(b.txs, func(i, j int) bool {
+		return b.txs[i].SpentComplexity > b.txs[j].SpentComplexity
+	})
+}
+
+// page returns the number of rows of the table of transactions or blocks fitting the terminal with the headers.
+func (b *browser) page() int {
+	return max(b.rows-9, 1)
+}
+
+func (b *browser) View() string {
+	sb := new(strings.Builder)
+	if b.opened != nil {
+		b.viewBlock(sb)
+	} else {
+		b.viewList(sb)
+	}
+	sb.WriteString("\n")
+	switch {
+	case b.err != nil:
+		sb.WriteString(b.c.red(b.err.Error()))
+	case b.pending != 0:
+		fmt.Fprintf(sb, "Analyzing block at height %d...", b.pending)
+	case b.loading != 0:
+		fmt.Fprintf(sb, "Analyzing block at height %d...", b.loading)
+	}
+	return sb.String()
+}
+
+func (b *browser) viewList(sb *strings.Builder) {
+	sb.WriteString(b.c.bold(fmt.Sprintf("Recent blocks up to height %d", b.top)) + "\n\n")
+	fmt.Fprintf(sb, "  %-8s  %-88s  %6s  %10s  %8s\n", "Height", "ID", "Txs", "Complexity", "Usage")
+	from, to := window(b.cursor, b.listed(), b.page())
+	for i := from; i < to; i++ {
+		h := b.top - uint64(i)
+		line := fmt.Sprintf("%-8d  ...", h)
+		if bc := b.blocks[h]; bc != nil {
+			line = fmt.Sprintf("%-8d  %-88s  %6d  %10d  %8.2f%%", h, bc.ID.String(), len(bc.Transactions), bc.Complexity, bc.Utilization)
+		}
+		sb.WriteString(b.selected(line, i == b.cursor) + "\n")
+	}
+	sb.WriteString("\n↑/↓ select  enter open  r reload  q quit\n")
+}
+
+func (b *browser) viewBlock(sb *strings.Builder) {
+	bc := b.opened
+	sb.WriteString(b.c.bold(fmt.Sprintf("Block %s at height %d", bc.ID.String(), bc.Height)) + "\n")
+	fmt.Fprintf(sb, "Forged by %s at %s, %d bytes\n", bc.Generator.String(), blockTime(*bc), bc.Size)
+	fmt.Fprintf(sb, "Complexity: %d of %d (%s), %d transactions, failed %d, fees %s WAVES\n",
+		bc.Complexity, bc.Limit, b.c.utilization(bc.Utilization), len(bc.Transactions), bc.FailedTransactions, waves(bc.Fees))
+	sb.WriteString("\n")
+	fmt.Fprintf(sb, "  %-44s  %-15s  %10s  %s\n", "ID", "Type", "Complexity", "Details")
+	from, to := window(b.row, len(b.txs), b.page())
+	for i := from; i < to; i++ {
+		c := b.txs[i]
+		sc := fmt.Sprintf("%10d", c.SpentComplexity)
+		if c.Unknown {
+			sc = fmt.Sprintf("%10s", "unknown")
+		}
+		var details []string
+		if c.Failed() {
+			details = append(details, "failed")
+		}
+		if c.Invocation != nil {
+			details = append(details, invocation(*c.Invocation))
+		}
+		line := strings.TrimRight(fmt.Sprintf("%-44s  %-15s  %s  %s", c.ID.String(), complexity.TransactionTypeName(c.Type), sc, strings.Join(details, ", ")), " ")
+		if b.highlight > 0 && c.SpentComplexity > b.highlight && i != b.row {
+			line = b.c.red(line)
+		}
+		sb.WriteString(b.selected(line, i == b.row) + "\n")
+	}
+	if len(b.txs) == 0 {
+		sb.WriteString("  No analyzed transactions\n")
+	}
+	sb.WriteString("\n↑/↓ select  ←/→ previous/next block  esc back  q quit\n")
+}
+
+// selected marks the selected row.
+func (b *browser) selected(line string, ok bool) string {
+	if ok {
+		return b.c.bold("> " + line)
+	}
+	return "  " + line
+}
+
+// window returns the range of rows of the table of n rows to show on a page, keeping the cursor visible.
+func window(cursor, n, page int) (int, int) {
+	from := max(cursor-page+1, 0)
+	return from, min(from+page, n)
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// diskCache stores responses as files in the directory, a file is named by the key and placed
+// in the subdirectory named by the first two characters of the key to keep directories small.
+type diskCache struct {
+	dir string
+}
+
+func newDiskCache(dir string) (*diskCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &diskCache{dir: dir}, nil
+}
+
+func (c *diskCache) path(key string) string {
+	shard := key
+	if len(shard) > 2 {
+		shard = shard[:2]
+	}
+	return filepath.Join(c.dir, shard, key+".json")
+}
+
+func (c *diskCache) Get(key string) ([]byte, bool) {
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			slog.Warn("Failed to read cache", "key", key, "error", err)
+		}
+		return nil, false
+	}
+	return data, true
+}
+
+// Put writes the response to a temporary file which is renamed then, so a partially written response is never read.
+func (c *diskCache) Put(key string, data []byte) {
+	name := c.path(key)
+	if err := c.write(name, data); err != nil {
+		slog.Warn("Failed to write cache", "key", key, "error", err)
+	}
+}
+
+func (c *diskCache) write(name string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(name), filepath.Base(name)+".*")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), name)
+}
+
+// maxMemoryCacheSize is the number of responses kept in memory, the cache is cleared once it is exceeded.
+const maxMemoryCacheSize = 10000
+
+// memoryCache keeps responses in memory for the duration of the run.
+type memoryCache struct {
+	mu sync.Mutex
+	m  map[string][]byte
+}
+
+func newMemoryCache() *memoryCache {
+	return &memoryCache{m: make(map[string][]byte)}
+}
+
+func (c *memoryCache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	data, ok := c.m[key]
+	return data, ok
+}
+
+func (c *memoryCache) Put(key string, data []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.m) >= maxMemoryCacheSize {
+		c.m = make(map[string][]byte)
+	}
+	c.m[key] = data
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"image/color"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/alexeykiselev/waves-block-complexity/pkg/complexity"
+	"github.com/pkg/errors"
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+	"gonum.org/v1/plot/vg/vgimg"
+	"gonum.org/v1/plot/vg/vgsvg"
+)
+
+const (
+	chartImageWidth  = 1000
+	chartImageHeight = 400
+)
+
+var (
+	chartComplexity  = color.RGBA{R: 0x4a, G: 0x7b, B: 0xd0, A: 0xff}
+	chartUtilization = color.RGBA{R: 0xd0, G: 0x57, B: 0x4a, A: 0xff}
+)
+
+// chartPrinter passes results to the underlying printer and renders the complexity of summarized blocks
+// as a line chart to the file on flush. The format of the chart, PNG or SVG, is selected by the file extension.
+type chartPrinter struct {
+	printer
+	name        string
+	utilization bool
+	blocks      []complexity.BlockComplexity
+}
+
+func newChartPrinter(out printer, name string, utilization bool) (*chartPrinter, error) {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".png", ".svg":
+		return &chartPrinter{printer: out, name: name, utilization: utilization}, nil
+	default:
+		return nil, errors.Errorf("unsupported chart file extension '%s', expected .png or .svg", filepath.Ext(name))
+	}
+}
+
+func (p *chartPrinter) summary(b complexity.BlockComplexity) error {
+	p.blocks = append(p.blocks, b)
+	return p.printer.summary(b)
+}
+
+func (p *chartPrinter) flush() error {
+	if len(p.blocks) > 0 {
+		if err := p.render(); err != nil {
+			return errors.Wrap(err, "failed to render chart")
+		}
+	}
+	return p.printer.flush()
+}
+
+func (p *chartPrinter) render() (err error) {
+	plots, err := p.plots()
+	if err != nil {
+		return err
+	}
+	w, h := vg.Length(chartImageWidth), vg.Length(chartImageHeight*len(plots))
+	var (
+		c  vg.CanvasWriterTo
+		dc draw.Canvas
+	)
+	if strings.ToLower(filepath.Ext(p.name)) == ".svg" {
+		sc := vgsvg.New(w, h)
+		c, dc = sc, draw.New(sc)
+	} else {
+		ic := vgimg.New(w, h)
+		c, dc = vgimg.PngCanvas{Canvas: ic}, draw.New(ic)
+	}
+	// Plots are stacked with aligned axes, so heights of both charts match
+	canvases := plot.Align(plots, draw.Tiles{Rows: len(plots), Cols: 1}, dc)
+	for i := range plots {
+		plots[i][0].Draw(canvases[i][0])
+	}
+	f, err := createOutput(p.name)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := f.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}()
+	_, err = c.WriteTo(f)
+	return err
+}
+
+// plots returns the plot of complexity of blocks by height, followed by the plot of their utilization if requested.
+func (p *chartPrinter) plots() ([][]*plot.Plot, error) {
+	cp, err := linePlot(p.blocks, "Complexity", chartComplexity, func(b complexity.BlockComplexity) float64 {
+		return float64(b.Complexity)
+	})
+	if err != nil {
+		return nil, err
+	}
+	r := [][]*plot.Plot{{cp}}
+	if p.utilization {
+		up, err := linePlot(p.blocks, "Utilization, %", chartUtilization, func(b complexity.BlockComplexity) float64 {
+			return b.Utilization
+		})
+		if err != nil {
+			return nil, err
+		}
+		up.Y.Max = 100
+		r = append(r, []*plot.Plot{up})
+	}
+	return r, nil
+}
+
+// linePlot plots the value of the blocks by their heights.
+func linePlot(blocks []complexity.BlockComplexity, label string, c color.Color, value func(complexity.BlockComplexity) float64) (*plot.Plot, error) {
+	pts := make(plotter.XYs, len(blocks))
+	for i, b := range blocks {
+		pts[i].X, pts[i].Y = float64(b.Height), value(b)
+	}
+	l, err := plotter.NewLine(pts)
+	if err != nil {
+		return nil, err
+	}
+	l.Color = c
+	r := plot.New()
+	r.X.Label.Text, r.Y.Label.Text = "Height", label
+	r.X.Tick.Marker, r.Y.Tick.Marker = integerTicks{}, integerTicks{}
+	r.Y.Min = 0
+	r.Add(plotter.NewGrid(), l)
+	return r, nil
+}
+
+// integerTicks are the default ticks of the axis labeled with whole numbers rather than in scientific notation.
+type integerTicks struct{}
+
+func (integerTicks) Ticks(min, max float64) []plot.Tick {
+	r := plot.DefaultTicks{}.Ticks(min, max)
+	for i := range r {
+		if r[i].Label != "" {
+			r[i].Label = strconv.FormatFloat(r[i].Value, 'f', -1, 64)
+		}
+	}
+	return r
+}
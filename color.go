@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+const (
+	defaultHighlight = 10000
+
+	ansiReset  = "\x1b[0m"
+	ansiBold   = "\x1b[1m"
+	ansiRed    = "\x1b[31m"
+	ansiGreen  = "\x1b[32m"
+	ansiYellow = "\x1b[33m"
+	ansiClear  = "\x1b[H\x1b[2J" // Moves the cursor home and clears the screen
+)
+
+// palette colors the text with ANSI escape sequences if enabled.
+type palette bool
+
+func (c palette) paint(code, s string) string {
+	if !c {
+		return s
+	}
+	return code + s + ansiReset
+}
+
+func (c palette) bold(s string) string {
+	return c.paint(ansiBold, s)
+}
+
+func (c palette) red(s string) string {
+	return c.paint(ansiRed, s)
+}
+
+func (c palette) yellow(s string) string {
+	return c.paint(ansiYellow, s)
+}
+
+// utilization formats the utilization percentage colored from green to red as it approaches the limit.
+func (c palette) utilization(u float64) string {
+	s := fmt.Sprintf("%.2f%%", u)
+	switch {
+	case u >= 80:
+		return c.paint(ansiRed, s)
+	case u >= 50:
+		return c.paint(ansiYellow, s)
+	default:
+		return c.paint(ansiGreen, s)
+	}
+}
+
+// colorful reports whether the output to the file should be colored: the file is a terminal
+// and colors are not disabled with NO_COLOR environment variable.
+func colorful(f *os.File) bool {
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return false
+	}
+	return terminal(f)
+}
+
+// terminal reports whether the file is a terminal.
+func terminal(f *os.File) bool {
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice != 0
+}
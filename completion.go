@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// flagValues lists the accepted values of flags for shell completion.
+var flagValues = map[string][]string{
+	"format":     {textFormat, jsonFormat, ndjsonFormat, csvFormat, markdownFormat, htmlFormat, influxFormat, xlsxFormat, parquetFormat, protoFormat, templateFormat},
+	"scheme":     {"W", "T", "S"},
+	"log-format": {textLogFormat, jsonLogFormat},
+	"sort":       {sortByPosition, sortByComplexity, sortByID, sortByType},
+}
+
+func init() {
+	commands = append(commands, command{
+		name:        "completion",
+		args:        "<bash|zsh|fish>",
+		description: "Print shell completion script",
+		offline:     true,
+		completions: []string{"bash", "zsh", "fish"},
+		flags:       func(fs *flag.FlagSet, o *options) {},
+		run:         runCompletion,
+	})
+}
+
+func runCompletion(_ context.Context, _ *options, args []string) error {
+	if len(args) != 1 {
+		err := errors.New("shell name is required")
+		slog.Error("Invalid parameters", "error", err)
+		return err
+	}
+	switch args[0] {
+	case "bash":
+		return bashCompletion(os.Stdout)
+	case "zsh":
+		return zshCompletion(os.Stdout)
+	case "fish":
+		return fishCompletion(os.Stdout)
+	default:
+		err := errors.Errorf("unsupported shell '%s'", args[0])
+		slog.Error("Invalid parameters", "error", err)
+		return err
+	}
+}
+
+// commandFlags returns the flags of the command sorted by name.
+func commandFlags(c *command) []*flag.Flag {
+	var r []*flag.Flag
+	c.flagSet(&options{}).VisitAll(func(f *flag.Flag) {
+		r = append(r, f)
+	})
+	return r
+}
+
+func commandNames() []string {
+	names := make([]string, 0, len(commands)+1)
+	for _, c := range commands {
+		names = append(names, c.name)
+	}
+	return append(names, "help")
+}
+
+func valueFlags() []string {
+	names := make([]string, 0, len(flagValues))
+	for n := range flagValues {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func bashCompletion(w io.Writer) error {
+	fn := "_" + strings.ReplaceAll(programName, "-", "_")
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s() {\n", fn)
+	b.WriteString("    local cur=\"${COMP_WORDS[COMP_CWORD]}\" prev=\"${COMP_WORDS[COMP_CWORD-1]}\"\n")
+	b.WriteString("    if [ \"$COMP_CWORD\" -eq 1 ]; then\n")
+	fmt.Fprintf(&b, "        COMPREPLY=($(compgen -W \"%s\" -- \"$cur\"))\n", strings.Join(commandNames(), " "))
+	b.WriteString("        return\n    fi\n")
+	b.WriteString("    case \"$prev\" in\n")
+	for _, n := range valueFlags() {
+		fmt.Fprintf(&b, "    -%s|--%s)\n        COMPREPLY=($(compgen -W \"%s\" -- \"$cur\"))\n        return\n        ;;\n",
+			n, n, strings.Join(flagValues[n], " "))
+	}
+	b.WriteString("    esac\n")
+	b.WriteString("    case \"${COMP_WORDS[1]}\" in\n")
+	for i := range commands {
+		names := commands[i].completions
+		for _, f := range commandFlags(&commands[i]) {
+			names = append(names, "-"+f.Name)
+		}
+		fmt.Fprintf(&b, "    %s)\n        COMPREPLY=($(compgen -W \"%s\" -- \"$cur\"))\n        ;;\n", commands[i].name, strings.Join(names, " "))
+	}
+	fmt.Fprintf(&b, "    help)\n        COMPREPLY=($(compgen -W \"%s\" -- \"$cur\"))\n        ;;\n", strings.Join(commandNames(), " "))
+	b.WriteString("    esac\n}\n")
+	fmt.Fprintf(&b, "complete -o default -F %s %s\n", fn, programName)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// zshCompletion reuses the bash completion script with zsh's bash compatibility layer.
+func zshCompletion(w io.Writer) error {
+	if _, err := fmt.Fprintf(w, "#compdef %s\nautoload -U +X bashcompinit && bashcompinit\n", programName); err != nil {
+		return err
+	}
+	return bashCompletion(w)
+}
+
+func fishCompletion(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "complete -c %s -f\n", programName)
+	for _, c := range commands {
+		fmt.Fprintf(&b, "complete -c %s -n __fish_use_subcommand -a %s -d %s\n", programName, c.name, fishQuote(c.description))
+	}
+	fmt.Fprintf(&b, "complete -c %s -n __fish_use_subcommand -a help -d %s\n", programName, fishQuote("Show help of a command"))
+	for i := range commands {
+		c := &commands[i]
+		for _, f := range commandFlags(c) {
+			fmt.Fprintf(&b, "complete -c %s -n '__fish_seen_subcommand_from %s' -o %s", programName, c.name, f.Name)
+			if values, ok := flagValues[f.Name]; ok {
+				fmt.Fprintf(&b, " -x -a %s", fishQuote(strings.Join(values, " ")))
+			}
+			fmt.Fprintf(&b, " -d %s\n", fishQuote(summary(f.Usage)))
+		}
+		if len(c.completions) > 0 {
+			fmt.Fprintf(&b, "complete -c %s -n '__fish_seen_subcommand_from %s' -a %s\n", programName, c.name, fishQuote(strings.Join(c.completions, " ")))
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// summary returns the first clause of the flag's usage.
+func summary(usage string) string {
+	for _, sep := range []string{" (", ", ", ". "} {
+		if i := strings.Index(usage, sep); i > 0 {
+			usage = usage[:i]
+		}
+	}
+	return usage
+}
+
+func fishQuote(s string) string {
+	return "'" + strings.ReplaceAll(strings.ReplaceAll(s, `\`, `\\`), "'", `\'`) + "'"
+}
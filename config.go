@@ -0,0 +1,92 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	defaultConfigName = ".waves-block-complexity.yaml"
+	envPrefix         = "WBC_"
+)
+
+// defaultConfigPath returns the path of the configuration file in the user's home directory.
+func defaultConfigPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, defaultConfigName)
+}
+
+// applyConfig sets the flags that were not given on the command line to the values from the configuration file.
+// The file is a YAML mapping of flag names to values, for example:
+//
+//	node: https://nodes.wavesnodes.com
+//	timeout: 30s
+//	concurrency: 16
+//	format: json
+//
+// Parameters of other commands are ignored. If the file is not required, its absence is not an error.
+func applyConfig(fs *flag.FlagSet, name string, required bool) error {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		if !required && errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	values := make(map[string]string)
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return errors.Wrap(err, "invalid configuration file")
+	}
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+	for k, v := range values {
+		if k == "config" || !knownFlag(k) {
+			return errors.Errorf("unknown parameter '%s'", k)
+		}
+		if fs.Lookup(k) == nil || set[k] {
+			continue
+		}
+		if err := fs.Set(k, v); err != nil {
+			return errors.Wrap(err, fmt.Sprintf("invalid value of parameter '%s'", k))
+		}
+	}
+	return nil
+}
+
+// envName returns the name of the environment variable of the flag, for example WBC_RETRY_BACKOFF for -retry-backoff.
+func envName(name string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// applyEnv sets the flags that were not given on the command line to the values of the environment variables.
+func applyEnv(fs *flag.FlagSet) error {
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+	var err error
+	fs.VisitAll(func(f *flag.Flag) {
+		if err != nil || set[f.Name] {
+			return
+		}
+		v, ok := os.LookupEnv(envName(f.Name))
+		if !ok {
+			return
+		}
+		if e := fs.Set(f.Name, v); e != nil {
+			err = errors.Wrap(e, fmt.Sprintf("invalid value of environment variable '%s'", envName(f.Name)))
+		}
+	})
+	return err
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronScheduleNext(t *testing.T) {
+	at := func(s string) time.Time {
+		r, err := time.Parse(time.DateTime, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return r
+	}
+	for _, test := range []struct {
+		expr, from, next string
+	}{
+		{"* * * * *", "2024-01-01 10:00:30", "2024-01-01 10:01:00"},
+		{"@hourly", "2024-01-01 10:00:00", "2024-01-01 11:00:00"},
+		{"@daily", "2024-12-31 23:59:00", "2025-01-01 00:00:00"},
+		{"*/15 * * * *", "2024-01-01 10:16:00", "2024-01-01 10:30:00"},
+		{"5/20 * * * *", "2024-01-01 10:26:00", "2024-01-01 10:45:00"},
+		{"0 8-18/5 * * *", "2024-01-01 14:00:00", "2024-01-01 18:00:00"},
+		{"0 0 29 2 *", "2023-03-01 00:00:00", "2024-02-29 00:00:00"},
+		{"0 0 29 2 *", "2024-02-29 00:00:00", "2028-02-29 00:00:00"},
+		{"0 0 * * 7", "2024-01-01 00:00:00", "2024-01-07 00:00:00"}, // Monday to Sunday
+		{"0 0 * * 0", "2024-01-01 00:00:00", "2024-01-07 00:00:00"},
+		{"0 0 * * 1-5", "2024-01-06 00:00:00", "2024-01-08 00:00:00"},
+		{"0 0 13 * 5", "2024-01-01 00:00:00", "2024-01-05 00:00:00"}, // Either the 13th or Friday
+		{"30 12 1,15 * *", "2024-01-02 00:00:00", "2024-01-15 12:30:00"},
+	} {
+		s, err := parseCron(test.expr)
+		if err != nil {
+			t.Fatalf("%s: %v", test.expr, err)
+		}
+		if next := s.next(at(test.from)); !next.Equal(at(test.next)) {
+			t.Errorf("%s from %s: expected %s, got %s", test.expr, test.from, test.next, next.Format(time.DateTime))
+		}
+	}
+}
+
+func TestCronScheduleNever(t *testing.T) {
+	for _, expr := range []string{"0 0 30 2 *", "0 0 31 4,6,9,11 *"} {
+		s, err := parseCron(expr)
+		if err != nil {
+			t.Fatalf("%s: %v", expr, err)
+		}
+		if next := s.next(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)); !next.IsZero() {
+			t.Errorf("%s: expected no time, got %s", expr, next)
+		}
+	}
+}
+
+func TestParseCronInvalid(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "* * * * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *",
+		"* * * * 8", "*/0 * * * *", "5-1 * * * *", "a * * * *", "1-b * * * *", "@yearly"} {
+		if _, err := parseCron(expr); err == nil {
+			t.Errorf("%s: expected error", expr)
+		}
+	}
+}
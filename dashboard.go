@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+)
+
+const (
+	dashboardSelector          = `{network=~"$network"}`
+	dashboardGeneratorSelector = `{network=~"$network",generator=~"$generator"}`
+)
+
+func init() {
+	commands = append(commands, command{
+		name:        "dashboard",
+		description: "Print Grafana dashboard of metrics of the exporter, ready to import",
+		offline:     true,
+		flags:       func(fs *flag.FlagSet, o *options) {},
+		run: func(_ context.Context, _ *options, args []string) error {
+			if err := noArguments(args); err != nil {
+				return err
+			}
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(dashboard())
+		},
+	})
+}
+
+// jsonObject is an object of the dashboard's JSON model.
+type jsonObject = map[string]interface{}
+
+// dashboard returns the JSON model of the dashboard. The Prometheus data source is selected on import,
+// the metrics are filtered by the network selected on the dashboard, and the complexity by generator is also
+// filtered by the selected generators.
+func dashboard() jsonObject {
+	ds := jsonObject{"type": "prometheus", "uid": "${DS_PROMETHEUS}"}
+	return jsonObject{
+		"__inputs": []jsonObject{{
+			"name":       "DS_PROMETHEUS",
+			"label":      "Prometheus",
+			"type":       "datasource",
+			"pluginId":   "prometheus",
+			"pluginName": "Prometheus",
+		}},
+		"title":         "Waves Block Complexity",
+		"uid":           "waves-block-complexity",
+		"tags":          []string{"waves"},
+		"schemaVersion": 39,
+		"editable":      true,
+		"refresh":       "1m",
+		"time":          jsonObject{"from": "now-6h", "to": "now"},
+		"templating": jsonObject{"list": []jsonObject{
+			dashboardVariable(ds, "network", "Network", "label_values("+heightMetric+", network)"),
+			dashboardVariable(ds, "generator", "Generator", `label_values(`+generatorComplexityMetric+`{network=~"$network"}, generator)`),
+		}},
+		"panels": []jsonObject{
+			dashboardPanel(ds, 1, "stat", "Height", "", 0, 0, 6,
+				dashboardTarget("max("+heightMetric+dashboardSelector+")", "Height")),
+			dashboardPanel(ds, 2, "stat", "Utilization", "percent", 6, 0, 6,
+				dashboardTarget("max("+utilizationMetric+dashboardSelector+")", "Utilization")),
+			dashboardPanel(ds, 3, "stat", "Transactions", "", 12, 0, 6,
+				dashboardTarget("max("+transactionsMetric+dashboardSelector+")", "Transactions")),
+			dashboardPanel(ds, 4, "stat", "Complexity Limit", "", 18, 0, 6,
+				dashboardTarget("max("+limitMetric+dashboardSelector+")", "Limit")),
+			dashboardPanel(ds, 5, "timeseries", "Block Complexity", "", 0, 4, 12,
+				dashboardTarget("max("+complexityMetric+dashboardSelector+")", "Complexity"),
+				dashboardTarget("max("+limitMetric+dashboardSelector+")", "Limit")),
+			dashboardPanel(ds, 6, "timeseries", "Utilization", "percent", 12, 4, 12,
+				dashboardTarget("max("+utilizationMetric+dashboardSelector+")", "Utilization")),
+			dashboardPanel(ds, 7, "timeseries", "Complexity by Transaction Type", "", 0, 12, 12,
+				dashboardTarget("sum by (type) ("+typeComplexityMetric+dashboardSelector+")", "{{type}}")),
+			dashboardPanel(ds, 8, "timeseries", "Transactions by Type", "", 12, 12, 12,
+				dashboardTarget("sum by (type) ("+typeTransactionsMetric+dashboardSelector+")", "{{type}}")),
+			dashboardPanel(ds, 9, "timeseries", "Complexity Rate by Generator", "", 0, 20, 24,
+				dashboardTarget("sum by (generator) (rate("+generatorComplexityMetric+dashboardGeneratorSelector+"[$__rate_interval]))", "{{generator}}")),
+		},
+	}
+}
+
+func dashboardVariable(ds jsonObject, name, label, query string) jsonObject {
+	return jsonObject{
+		"name":       name,
+		"label":      label,
+		"type":       "query",
+		"datasource": ds,
+		"query":      jsonObject{"query": query, "refId": name},
+		"refresh":    2,
+		"multi":      true,
+		"includeAll": true,
+		"current":    jsonObject{"text": "All", "value": "$__all"},
+		"sort":       1,
+	}
+}
+
+// dashboardPanel returns the panel of the type placed at the position of the grid of 24 columns, stat panels are
+// 4 rows high and other panels are 8 rows high.
+func dashboardPanel(ds jsonObject, id int, kind, title, unit string, x, y, w int, targets ...jsonObject) jsonObject {
+	h := 8
+	if kind == "stat" {
+		h = 4
+	}
+	for i, t := range targets {
+		t["datasource"] = ds
+		t["refId"] = string(rune('A' + i))
+	}
+	defaults := jsonObject{}
+	if unit != "" {
+		defaults["unit"] = unit
+	}
+	return jsonObject{
+		"id":          id,
+		"type":        kind,
+		"title":       title,
+		"datasource":  ds,
+		"gridPos":     jsonObject{"x": x, "y": y, "w": w, "h": h},
+		"fieldConfig": jsonObject{"defaults": defaults, "overrides": []jsonObject{}},
+		"targets":     targets,
+	}
+}
+
+func dashboardTarget(expr, legend string) jsonObject {
+	return jsonObject{"expr": expr, "legendFormat": legend}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"expvar"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/pprof"
+
+	"github.com/pkg/errors"
+)
+
+// startDebugServer starts serving profiles of net/http/pprof under /debug/pprof/ and variables of expvar, including
+// memory statistics, under /debug/vars on the address until the context is canceled. The command line is not
+// exposed, because it may contain credentials.
+func startDebugServer(ctx context.Context, addr string) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.HandleFunc("/debug/vars", vars)
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	srv := &http.Server{Handler: mux}
+	go func() {
+		if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("Failed to serve debug endpoints", "address", addr, "error", err)
+		}
+	}()
+	go func() {
+		<-ctx.Done()
+		sctx, done := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer done()
+		_ = srv.Shutdown(sctx)
+	}()
+	slog.Info("Serving debug endpoints", "address", ln.Addr().String())
+	return nil
+}
+
+// vars writes the published variables as expvar.Handler does, except the command line.
+func vars(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	fmt.Fprintf(w, "{\n")
+	first := true
+	expvar.Do(func(kv expvar.KeyValue) {
+		if kv.Key == "cmdline" {
+			return
+		}
+		if !first {
+			fmt.Fprintf(w, ",\n")
+		}
+		first = false
+		fmt.Fprintf(w, "%q: %s", kv.Key, kv.Value)
+	})
+	fmt.Fprintf(w, "\n}\n")
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/alexeykiselev/waves-block-complexity/pkg/complexity"
+	"github.com/pkg/errors"
+)
+
+const (
+	dailyDigest  = "daily"
+	weeklyDigest = "weekly"
+
+	digestTop = 5 // Number of the heaviest blocks and dApps with the highest complexity listed in a digest
+)
+
+// digestPrinter passes results to the underlying printer and aggregates statistics of processed blocks by days or
+// weeks starting on Monday in UTC, given by timestamps of blocks. The digest of a period is sent to the notifiers
+// once the first block of the next period is processed, the statistics of the incomplete period are not sent.
+type digestPrinter struct {
+	printer
+	period    string
+	notifiers []notifier
+
+	start  time.Time // Start of the period of the aggregated blocks, zero if no blocks are aggregated yet
+	acc    *complexity.Accumulator
+	blocks []complexity.BlockComplexity // The heaviest blocks without transactions
+	dApps  map[string]*complexity.DAppComplexity
+}
+
+func newDigestPrinter(out printer, period string, notifiers []notifier) (*digestPrinter, error) {
+	switch period {
+	case dailyDigest, weeklyDigest:
+		return &digestPrinter{printer: out, period: period, notifiers: notifiers}, nil
+	default:
+		return nil, errors.Errorf("unsupported digest period '%s', expected %s or %s", period, dailyDigest, weeklyDigest)
+	}
+}
+
+func (p *digestPrinter) block(b complexity.BlockComplexity) error {
+	p.add(b)
+	return p.printer.block(b)
+}
+
+func (p *digestPrinter) summary(b complexity.BlockComplexity) error {
+	p.add(b)
+	return p.printer.summary(b)
+}
+
+func (p *digestPrinter) add(b complexity.BlockComplexity) {
+	start := p.periodStart(time.UnixMilli(int64(b.Timestamp)).UTC())
+	if !p.start.IsZero() && start.After(p.start) {
+		p.send()
+	}
+	if p.start.IsZero() || start.After(p.start) {
+		p.start, p.acc, p.blocks = start, complexity.NewAccumulator(), nil
+		p.dApps = make(map[string]*complexity.DAppComplexity)
+	}
+	p.acc.Add(b)
+	for _, d := range b.DApps() {
+		dc, ok := p.dApps[d.DApp]
+		if !ok {
+			dc = &complexity.DAppComplexity{DApp: d.DApp}
+			p.dApps[d.DApp] = dc
+		}
+		dc.Transactions += d.Transactions
+		dc.Complexity += d.Complexity
+	}
+	heavy := complexity.BlockComplexity{ID: b.ID, Height: b.Height, Complexity: b.Complexity, Utilization: b.Utilization}
+	p.blocks = append(p.blocks, heavy)
+	sort.SliceStable(p.blocks, func(i, j int) bool { return p.blocks[i].Complexity > p.blocks[j].Complexity })
+	if len(p.blocks) > digestTop {
+		p.blocks = p.blocks[:digestTop]
+	}
+}
+
+// periodStart returns the start of the day or the week of the time.
+func (p *digestPrinter) periodStart(t time.Time) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	if p.period == weeklyDigest {
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	}
+	return day
+}
+
+func (p *digestPrinter) send() {
+	dApps := make([]complexity.DAppComplexity, 0, len(p.dApps))
+	for _, dc := range p.dApps {
+		dApps = append(dApps, *dc)
+	}
+	sort.Slice(dApps, func(i, j int) bool {
+		if dApps[i].Complexity != dApps[j].Complexity {
+			return dApps[i].Complexity > dApps[j].Complexity
+		}
+		return dApps[i].DApp < dApps[j].DApp
+	})
+	if len(dApps) > digestTop {
+		dApps = dApps[:digestTop]
+	}
+	text := p.digest(p.acc.Stats(), dApps)
+	for _, n := range p.notifiers {
+		if err := n.send(text); err != nil {
+			slog.Warn("Failed to send digest", "channel", n.name(), "period", p.start.Format(time.DateOnly), "error", err)
+		}
+	}
+}
+
+// digest formats the statistics of blocks of the period as a message.
+func (p *digestPrinter) digest(s complexity.RangeStats, dApps []complexity.DAppComplexity) string {
+	var sb strings.Builder
+	if p.period == weeklyDigest {
+		fmt.Fprintf(&sb, "*Weekly digest for the week of %s*\n", p.start.Format(time.DateOnly))
+	} else {
+		fmt.Fprintf(&sb, "*Daily digest for %s*\n", p.start.Format(time.DateOnly))
+	}
+	fmt.Fprintf(&sb, "Blocks: %d, transactions: %d\n", s.Blocks, s.Transactions)
+	fmt.Fprintf(&sb, "Total Complexity: %d\n", s.Complexity)
+	fmt.Fprintf(&sb, "Failed Transactions Complexity: %d (%d transactions)\n", s.FailedComplexity, s.FailedTransactions)
+	fmt.Fprintf(&sb, "Average Block Complexity: %d\n", s.AverageComplexity)
+	fmt.Fprintf(&sb, "Max Block Complexity: %d at height %d\n", s.MaxComplexity, s.MaxHeight)
+	fmt.Fprintf(&sb, "Average Utilization: %.2f%%\n", s.AverageUtilization)
+	fmt.Fprintf(&sb, "Max Utilization: %.2f%%", s.MaxUtilization)
+	if len(p.blocks) > 0 {
+		sb.WriteString("\n\nHeaviest Blocks:")
+		for _, b := range p.blocks {
+			fmt.Fprintf(&sb, "\n%d\t%s\t%d\t%.2f%%", b.Height, b.ID.String(), b.Complexity, b.Utilization)
+		}
+	}
+	if len(dApps) > 0 {
+		sb.WriteString("\n\nTop dApps:")
+		for _, d := range dApps {
+			fmt.Fprintf(&sb, "\n%s\t%d\t%d", d.DApp, d.Transactions, d.Complexity)
+		}
+	}
+	return sb.String()
+}
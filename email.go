@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// emailNotifier sends messages by e-mail through the SMTP server, the first line of a message is used as the
+// subject. The connection is upgraded with STARTTLS if the server supports it.
+type emailNotifier struct {
+	addr     string // Address of the SMTP server as host:port
+	user     string // No authentication if empty
+	password string
+	from     string
+	to       []string
+}
+
+func newEmailNotifier(addr, user, password, from string, to []string) (*emailNotifier, error) {
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		return nil, errors.Wrap(err, "invalid SMTP server address")
+	}
+	if from == "" {
+		return nil, errors.New("sender address is required")
+	}
+	if len(to) == 0 {
+		return nil, errors.New("at least one recipient is required")
+	}
+	return &emailNotifier{addr: addr, user: user, password: password, from: from, to: to}, nil
+}
+
+func (n *emailNotifier) send(text string) error {
+	subject, body, _ := strings.Cut(text, "\n")
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", n.from)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(n.to, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", strings.Trim(subject, "*")))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(strings.TrimLeft(body, "\n"), "\n", "\r\n"))
+	msg.WriteString("\r\n")
+	var auth smtp.Auth
+	if n.user != "" {
+		host, _, _ := net.SplitHostPort(n.addr)
+		auth = smtp.PlainAuth("", n.user, n.password, host)
+	}
+	return smtp.SendMail(n.addr, auth, n.from, n.to, msg.Bytes())
+}
+
+func (n *emailNotifier) name() string {
+	return "email"
+}
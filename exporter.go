@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/alexeykiselev/waves-block-complexity/pkg/complexity"
+	"github.com/wavesplatform/gowaves/pkg/proto"
+)
+
+// Names of the exported metrics, every metric is labeled by the network.
+const (
+	heightMetric           = "waves_block_height"
+	complexityMetric       = "waves_block_complexity"
+	limitMetric            = "waves_block_complexity_limit"
+	utilizationMetric      = "waves_block_complexity_utilization"
+	transactionsMetric     = "waves_block_tx_count"
+	typeComplexityMetric   = "waves_block_type_complexity" // Labeled by transaction type as well
+	typeTransactionsMetric = "waves_block_type_tx_count"   // Labeled by transaction type as well
+	// generatorComplexityMetric is the counter of the complexity of the blocks by their generator, labeled by
+	// the generator as well. Gauges of the last block are not labeled by its generator, because every new
+	// generator would start another series, leaving the series of the previous one stale.
+	generatorComplexityMetric = "waves_generator_complexity_total"
+)
+
+// metricsExporter is a printer that keeps the complexity of the last processed block and exposes it
+// as Prometheus metrics in the text exposition format. The complexity of the blocks processed since the start
+// is counted by their generators.
+type metricsExporter struct {
+	mu         sync.Mutex
+	last       *complexity.BlockComplexity
+	generators map[proto.WavesAddress]uint64 // Total complexity of the processed blocks by generator
+}
+
+func (e *metricsExporter) block(b complexity.BlockComplexity) error {
+	return e.summary(b)
+}
+
+func (e *metricsExporter) summary(b complexity.BlockComplexity) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	// A block is counted once even if it's reported again
+	if e.last == nil || b.Height > e.last.Height {
+		if e.generators == nil {
+			e.generators = make(map[proto.WavesAddress]uint64)
+		}
+		e.generators[b.Generator] += uint64(b.Complexity)
+	}
+	e.last = &b
+	return nil
+}
+
+func (e *metricsExporter) stats(complexity.RangeStats) error {
+	return nil
+}
+
+func (e *metricsExporter) flush() error {
+	return nil
+}
+
+func (e *metricsExporter) streaming() bool {
+	return true
+}
+
+func (e *metricsExporter) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if e.last == nil {
+		return
+	}
+	b := e.last
+	network := networkLabel(b.Generator.Bytes()[1])
+	labels := fmt.Sprintf("network=%q", network)
+	gauge(w, heightMetric, "Height of the last processed block.")
+	fmt.Fprintf(w, "%s{%s} %d\n", heightMetric, labels, b.Height)
+	gauge(w, complexityMetric, "Spent complexity of the last processed block.")
+	fmt.Fprintf(w, "%s{%s} %d\n", complexityMetric, labels, b.Complexity)
+	gauge(w, limitMetric, "Complexity limit of the last processed block, zero if not limited.")
+	fmt.Fprintf(w, "%s{%s} %d\n", limitMetric, labels, b.Limit)
+	gauge(w, utilizationMetric, "Spent complexity of the last processed block as a percentage of the limit.")
+	fmt.Fprintf(w, "%s{%s} %g\n", utilizationMetric, labels, b.Utilization)
+	gauge(w, transactionsMetric, "Number of transactions in the last processed block.")
+	fmt.Fprintf(w, "%s{%s} %d\n", transactionsMetric, labels, len(b.Transactions))
+	gauge(w, typeComplexityMetric, "Spent complexity of the last processed block by transaction type.")
+	for _, t := range b.Types {
+		fmt.Fprintf(w, "%s{%s,type=%q} %d\n", typeComplexityMetric, labels, complexity.TransactionTypeName(t.Type), t.Complexity)
+	}
+	gauge(w, typeTransactionsMetric, "Number of transactions in the last processed block by transaction type.")
+	for _, t := range b.Types {
+		fmt.Fprintf(w, "%s{%s,type=%q} %d\n", typeTransactionsMetric, labels, complexity.TransactionTypeName(t.Type), t.Transactions)
+	}
+	generators := make([]proto.WavesAddress, 0, len(e.generators))
+	for g := range e.generators {
+		generators = append(generators, g)
+	}
+	sort.Slice(generators, func(i, j int) bool { return generators[i].String() < generators[j].String() })
+	counter(w, generatorComplexityMetric, "Spent complexity of the blocks processed since the start by their generator.")
+	for _, g := range generators {
+		fmt.Fprintf(w, "%s{%s,generator=%q} %d\n", generatorComplexityMetric, labels, g.String(), e.generators[g])
+	}
+}
+
+// networkLabel returns the name of the network for the label, or the chain ID of a custom network.
+func networkLabel(scheme proto.Scheme) string {
+	switch scheme {
+	case proto.MainNetScheme, proto.TestNetScheme, proto.StageNetScheme:
+		return complexity.NetworkName(scheme)
+	default:
+		return string(rune(scheme))
+	}
+}
+
+func gauge(w http.ResponseWriter, name, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", name, help, name)
+}
+
+func counter(w http.ResponseWriter, name, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", name, help, name)
+}
+
+// export runs the HTTP server with the metrics endpoint while following new blocks.
+func (p *processor) export(ctx context.Context, addr string, m *metricsExporter, poll time.Duration) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", m)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	errs := make(chan error, 1)
+	go func() {
+		errs <- listen(ctx, addr, mux)
+		cancel()
+	}()
+	err := p.follow(ctx, poll, 0)
+	cancel()
+	if lerr := <-errs; lerr != nil && lerr != context.Canceled {
+		slog.Error("Failed to serve metrics", "error", lerr)
+		return lerr
+	}
+	return err
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"strconv"
+
+	"github.com/alexeykiselev/waves-block-complexity/pkg/complexity"
+)
+
+const (
+	chartWidth  = 800
+	chartHeight = 200
+	// chartAxisHeight is the height of the axis of the chart below the bars, with the ranges of the buckets
+	chartAxisHeight = 20
+)
+
+// htmlPrinter collects results and writes them as a single-file HTML report on flush.
+type htmlPrinter struct {
+	w        io.Writer
+	detailed *complexity.BlockComplexity
+	blocks   []complexity.BlockComplexity
+	totals   *complexity.RangeStats
+}
+
+func (p *htmlPrinter) block(b complexity.BlockComplexity) error {
+	p.detailed = &b
+	return nil
+}
+
+func (p *htmlPrinter) summary(b complexity.BlockComplexity) error {
+	p.blocks = append(p.blocks, b)
+	return nil
+}
+
+func (p *htmlPrinter) stats(s complexity.RangeStats) error {
+	p.totals = &s
+	return nil
+}
+
+func (p *htmlPrinter) streaming() bool {
+	return false
+}
+
+type htmlCard struct {
+	Title string
+	Value string
+}
+
+type htmlBar struct {
+	X, Y, Width, Height float64
+	Range               string
+	Label               string
+}
+
+type htmlTable struct {
+	Header []string
+	Rows   [][]string
+}
+
+type htmlReport struct {
+	Title  string
+	Cards  []htmlCard
+	Chart  []htmlBar
+	Tables []htmlTable
+	Width  int
+	Height int
+}
+
+func (p *htmlPrinter) flush() error {
+	var r htmlReport
+	switch {
+	case p.detailed != nil:
+		b := p.detailed
+		r.Title = fmt.Sprintf("Block %s at height %d", b.ID.String(), b.Height)
+		r.Cards = []htmlCard{
+			{"Complexity", strconv.Itoa(b.Complexity)},
+			{"Transactions", strconv.Itoa(len(b.Transactions))},
+			{"Failed Complexity", strconv.Itoa(b.FailedComplexity)},
+			{"Unknown Complexity Transactions", strconv.Itoa(b.UnknownTransactions)},
+			{"Utilization", fmt.Sprintf("%.2f%%", b.Utilization)},
+			{"Median Transaction Complexity", strconv.Itoa(b.Distribution.Median)},
+			{"P95 Transaction Complexity", strconv.Itoa(b.Distribution.P95)},
+			{"Time", blockTime(*b)},
+			{"Generator", b.Generator.String()},
+			{"Size", fmt.Sprintf("%d bytes", b.Size)},
+			{"Base Target", strconv.FormatUint(b.BaseTarget, 10)},
+			{"Fees", waves(b.Fees) + " WAVES"},
+		}
+		r.Chart = bars(b.Histogram)
+		r.Tables = []htmlTable{
+			{Header: []string{"Transaction", "Type", "Status", "Complexity"}, Rows: transactionRows(b.Transactions)},
+			{Header: []string{"Type", "Transactions", "Complexity"}, Rows: typeRows(b.Types)},
+		}
+	case len(p.blocks) > 0:
+		r.Title = "Blocks Complexity"
+		if s := p.totals; s != nil {
+			r.Title = fmt.Sprintf("Blocks from %d to %d", p.blocks[0].Height, p.blocks[len(p.blocks)-1].Height)
+			r.Cards = []htmlCard{
+				{"Blocks", strconv.FormatUint(s.Blocks, 10)},
+				{"Total Complexity", strconv.Itoa(s.Complexity)},
+				{"Average Complexity", strconv.Itoa(s.AverageComplexity)},
+				{"Max Complexity", fmt.Sprintf("%d at %d", s.MaxComplexity, s.MaxHeight)},
+				{"Average Utilization", fmt.Sprintf("%.2f%%", s.AverageUtilization)},
+				{"Max Utilization", fmt.Sprintf("%.2f%%", s.MaxUtilization)},
+				{"Median Complexity", strconv.Itoa(s.Distribution.Median)},
+				{"P95 Complexity", strconv.Itoa(s.Distribution.P95)},
+			}
+		}
+		rows := make([][]string, len(p.blocks))
+		for i, b := range p.blocks {
+			rows[i] = []string{
+				strconv.FormatUint(b.Height, 10),
+				b.ID.String(),
+				blockTime(b),
+				b.Generator.String(),
+				strconv.FormatUint(b.Size, 10),
+				strconv.FormatUint(b.BaseTarget, 10),
+				strconv.Itoa(len(b.Transactions)),
+				strconv.Itoa(b.Complexity),
+				fmt.Sprintf("%.2f", b.Utilization),
+				waves(b.Fees),
+			}
+		}
+		r.Chart = bars(p.histogram())
+		r.Tables = []htmlTable{{Header: []string{"Height", "Block", "Time", "Generator", "Size", "Base Target", "Transactions", "Complexity", "Utilization, %", "Fees, WAVES"}, Rows: rows}}
+		if p.totals != nil {
+			r.Tables = append(r.Tables, htmlTable{Header: []string{"Type", "Transactions", "Complexity"}, Rows: typeRows(p.totals.Types)})
+		}
+	default:
+		return nil
+	}
+	r.Width, r.Height = chartWidth, chartHeight+chartAxisHeight
+	return htmlReportTemplate.Execute(p.w, r)
+}
+
+// histogram returns the histogram of transaction complexity of the range, summing up the histograms of the blocks
+// if the stats of the range are not collected.
+func (p *htmlPrinter) histogram() []complexity.Bucket {
+	if p.totals != nil {
+		return p.totals.Histogram
+	}
+	var r []complexity.Bucket
+	for _, b := range p.blocks {
+		if r == nil {
+			r = append([]complexity.Bucket(nil), b.Histogram...)
+			continue
+		}
+		if len(b.Histogram) != len(r) {
+			continue
+		}
+		for i := range b.Histogram {
+			r[i].Transactions += b.Histogram[i].Transactions
+			r[i].Complexity += b.Histogram[i].Complexity
+		}
+	}
+	return r
+}
+
+// bars scales the numbers of transactions in the buckets of the histogram to the bars of the chart.
+func bars(buckets []complexity.Bucket) []htmlBar {
+	max := 0
+	for _, b := range buckets {
+		if b.Transactions > max {
+			max = b.Transactions
+		}
+	}
+	if max == 0 {
+		return nil
+	}
+	w := float64(chartWidth) / float64(len(buckets))
+	r := make([]htmlBar, len(buckets))
+	for i, b := range buckets {
+		h := float64(b.Transactions) / float64(max) * chartHeight
+		r[i] = htmlBar{X: float64(i) * w, Y: chartHeight - h, Width: w * 0.9, Height: h, Range: b.Range,
+			Label: fmt.Sprintf("%s: %d transactions, complexity %d", b.Range, b.Transactions, b.Complexity)}
+	}
+	return r
+}
+
+var htmlReportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"center": func(x, w float64) float64 { return x + w/2 },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+.cards { display: flex; flex-wrap: wrap; gap: 1em; margin-bottom: 2em; }
+.card { border: 1px solid #ddd; border-radius: 6px; padding: 1em 1.5em; }
+.card .title { color: #666; font-size: 0.9em; }
+.card .value { font-size: 1.5em; font-weight: bold; }
+svg rect { fill: #4a7bd0; }
+svg rect:hover { fill: #d0574a; }
+svg text { font-size: 12px; fill: #666; dominant-baseline: text-after-edge; }
+table { border-collapse: collapse; margin: 2em 0; }
+th, td { border: 1px solid #ddd; padding: 0.3em 0.8em; text-align: right; font-family: monospace; }
+th { cursor: pointer; background: #f4f4f4; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<div class="cards">
+{{- range .Cards}}
+<div class="card"><div class="title">{{.Title}}</div><div class="value">{{.Value}}</div></div>
+{{- end}}
+</div>
+{{- if .Chart}}
+<svg width="{{.Width}}" height="{{.Height}}" viewBox="0 0 {{.Width}} {{.Height}}">
+{{- range .Chart}}
+<rect x="{{printf "%.2f" .X}}" y="{{printf "%.2f" .Y}}" width="{{printf "%.2f" .Width}}" height="{{printf "%.2f" .Height}}"><title>{{.Label}}</title></rect>
+<text x="{{printf "%.2f" (center .X .Width)}}" y="{{$.Height}}" text-anchor="middle">{{.Range}}</text>
+{{- end}}
+</svg>
+{{- end}}
+{{- range .Tables}}
+{{- if .Rows}}
+<table class="sortable">
+<thead><tr>{{range .Header}}<th>{{.}}</th>{{end}}</tr></thead>
+<tbody>
+{{- range .Rows}}
+<tr>{{range .}}<td>{{.}}</td>{{end}}</tr>
+{{- end}}
+</tbody>
+</table>
+{{- end}}
+{{- end}}
+<script>
+document.querySelectorAll("table.sortable th").forEach(function (th) {
+  th.addEventListener("click", function () {
+    var table = th.closest("table"), body = table.tBodies[0];
+    var i = Array.prototype.indexOf.call(th.parentNode.children, th);
+    var asc = th.dataset.order !== "asc";
+    th.dataset.order = asc ? "asc" : "desc";
+    Array.from(body.rows).sort(function (a, b) {
+      var x = a.cells[i].textContent, y = b.cells[i].textContent;
+      var nx = parseFloat(x), ny = parseFloat(y);
+      var c = isNaN(nx) || isNaN(ny) ? x.localeCompare(y) : nx - ny;
+      return asc ? c : -c;
+    }).forEach(function (r) { body.appendChild(r); });
+  });
+});
+</script>
+</body>
+</html>
+`))
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/alexeykiselev/waves-block-complexity/pkg/complexity"
+)
+
+// importBlocks reports the summaries and statistics of the blocks of the export file at heights from `from` to `to`
+// inclusive, up to the last block of the file if `to` is zero. The preceding blocks are read to collect scripts
+// and aliases and the activation of features.
+func (p *processor) importBlocks(ctx context.Context, opts complexity.Options, name string, from, to uint64) error {
+	off, err := complexity.NewOffline(opts)
+	if err != nil {
+		slog.Error("Invalid parameters", "error", err)
+		return err
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		slog.Error("Failed to open export file", "file", name, "error", err)
+		return err
+	}
+	defer f.Close()
+	r := complexity.NewExportReader(f, off.Scheme())
+	acc := complexity.NewAccumulator()
+	for h := uint64(0); to == 0 || h < to; {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		b, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			slog.Error("Failed to read export file", "file", name, "error", err)
+			return err
+		}
+		h = b.Height
+		if h < from {
+			if err := off.Skip(b); err != nil {
+				slog.Error("Failed to apply block", "height", b.Height, "error", err)
+				return err
+			}
+			continue
+		}
+		bc, err := off.Analyze(ctx, b)
+		if err != nil {
+			slog.Error("Failed to analyze block", "height", b.Height, "error", err)
+			return err
+		}
+		acc.Add(*bc)
+		if err := p.summary(*bc); err != nil {
+			return err
+		}
+	}
+	return p.out.stats(acc.Stats())
+}
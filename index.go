@@ -0,0 +1,260 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/alexeykiselev/waves-block-complexity/pkg/complexity"
+	_ "github.com/lib/pq"
+	"github.com/pkg/errors"
+	_ "modernc.org/sqlite"
+)
+
+const defaultIndexDB = "waves-block-complexity.db"
+
+const (
+	sqliteDriver   = "sqlite"
+	postgresDriver = "postgres"
+)
+
+// indexSchema is compatible with both SQLite and PostgreSQL and can be applied repeatedly.
+const indexSchema = `
+CREATE TABLE IF NOT EXISTS blocks (
+	height BIGINT PRIMARY KEY,
+	id TEXT NOT NULL UNIQUE,
+	transactions INTEGER NOT NULL,
+	complexity BIGINT NOT NULL,
+	failed_transactions INTEGER NOT NULL,
+	failed_complexity BIGINT NOT NULL,
+	complexity_limit BIGINT NOT NULL,
+	utilization DOUBLE PRECISION NOT NULL,
+	timestamp BIGINT,
+	generator TEXT,
+	size INTEGER,
+	base_target BIGINT,
+	fees BIGINT
+);
+CREATE TABLE IF NOT EXISTS transactions (
+	id TEXT PRIMARY KEY,
+	height BIGINT NOT NULL REFERENCES blocks (height),
+	type INTEGER NOT NULL,
+	sender TEXT NOT NULL,
+	status TEXT NOT NULL,
+	complexity INTEGER
+);
+CREATE INDEX IF NOT EXISTS transactions_height ON transactions (height);
+`
+
+// blockColumns are the columns added to the blocks table after its creation, in order of addition. They are
+// nullable, because blocks stored before are missing them.
+var blockColumns = []struct{ name, typ string }{
+	{"timestamp", "BIGINT"},
+	{"generator", "TEXT"},
+	{"size", "INTEGER"},
+	{"base_target", "BIGINT"},
+	{"fees", "BIGINT"},
+}
+
+// index is the database storing complexities of blocks and transactions.
+type index struct {
+	db     *sql.DB
+	driver string
+}
+
+// openIndex opens the database with the driver (SQLite or PostgreSQL) creating its tables if necessary.
+func openIndex(driver, dsn string) (*index, error) {
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(indexSchema); err != nil {
+		db.Close()
+		return nil, errors.Wrap(err, "failed to create index tables")
+	}
+	ix := &index{db: db, driver: driver}
+	if err := ix.migrate(); err != nil {
+		db.Close()
+		return nil, errors.Wrap(err, "failed to upgrade index tables")
+	}
+	return ix, nil
+}
+
+// migrate upgrades the tables created by the previous versions.
+func (ix *index) migrate() error {
+	columns, err := ix.columns("blocks")
+	if err != nil {
+		return err
+	}
+	for _, c := range blockColumns {
+		if _, ok := columns[c.name]; ok {
+			continue
+		}
+		if _, err := ix.db.Exec("ALTER TABLE blocks ADD COLUMN " + c.name + " " + c.typ); err != nil {
+			return errors.Wrapf(err, "failed to add column '%s'", c.name)
+		}
+	}
+	txColumns, err := ix.columns("transactions")
+	if err != nil {
+		return err
+	}
+	// Complexity of transactions is null if the node doesn't report it
+	if txColumns["complexity"] {
+		if err := ix.nullableComplexity(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// columns returns the columns of the table, reporting whether they are NOT NULL.
+func (ix *index) columns(table string) (map[string]bool, error) {
+	q := "SELECT name, \"notnull\" = 1 FROM pragma_table_info(?)"
+	if ix.driver == postgresDriver {
+		q = "SELECT column_name, is_nullable = 'NO' FROM information_schema.columns WHERE table_schema = current_schema() AND table_name = ?"
+	}
+	rows, err := ix.db.Query(ix.query(q), table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	r := make(map[string]bool)
+	for rows.Next() {
+		var (
+			name    string
+			notNull bool
+		)
+		if err := rows.Scan(&name, &notNull); err != nil {
+			return nil, err
+		}
+		r[name] = notNull
+	}
+	return r, rows.Err()
+}
+
+// nullableComplexity removes NOT NULL constraint of the complexity of transactions. SQLite can't alter constraints
+// of columns, so the table is recreated from the current schema there.
+func (ix *index) nullableComplexity() error {
+	if ix.driver == postgresDriver {
+		_, err := ix.db.Exec("ALTER TABLE transactions ALTER COLUMN complexity DROP NOT NULL")
+		return err
+	}
+	tx, err := ix.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, q := range []string{
+		"ALTER TABLE transactions RENAME TO transactions_old",
+		"DROP INDEX IF EXISTS transactions_height",
+		indexSchema,
+		"INSERT INTO transactions SELECT * FROM transactions_old",
+		"DROP TABLE transactions_old",
+	} {
+		if _, err := tx.Exec(q); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (ix *index) close() error {
+	return ix.db.Close()
+}
+
+// query replaces '?' placeholders of the query with the numbered ones if required by the database.
+func (ix *index) query(q string) string {
+	if ix.driver != postgresDriver {
+		return q
+	}
+	var sb strings.Builder
+	n := 0
+	for _, r := range q {
+		if r == '?' {
+			n++
+			sb.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// last returns the height of the last stored block, zero if the index is empty.
+func (ix *index) last(ctx context.Context) (uint64, error) {
+	var last sql.NullInt64
+	if err := ix.db.QueryRowContext(ctx, "SELECT MAX(height) FROM blocks").Scan(&last); err != nil {
+		return 0, err
+	}
+	return uint64(last.Int64), nil
+}
+
+// index stores complexities of blocks and their transactions to the database, starting from the height next to
+// the last stored block or from the given height if the database is empty, up to the given height inclusive,
+// or up to the last finalized block if the height is zero.
+func (p *processor) index(ctx context.Context, ix *index, from, to uint64) error {
+	last, err := ix.last(ctx)
+	if err != nil {
+		slog.Error("Failed to read index", "error", err)
+		return err
+	}
+	if last >= from {
+		from = last + 1
+	}
+	if to == 0 {
+		h, err := p.an.Height(ctx)
+		if err != nil {
+			slog.Error("Failed to get blockchain height", "error", err)
+			return err
+		}
+		to = h - 1
+	}
+	if from > to {
+		slog.Info("Index is up to date", "height", from-1)
+		return nil
+	}
+	slog.Info("Indexing blocks", "from", from, "to", to)
+	_, err = p.an.Range(ctx, from, to, func(bc complexity.BlockComplexity) error {
+		p.progress.block(to - from + 1)
+		if err := ix.store(ctx, bc); err != nil {
+			slog.Error("Failed to store block", "height", bc.Height, "error", err)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		slog.Error("Failed to index blocks", "error", err)
+		return err
+	}
+	return nil
+}
+
+// store inserts the block and its transactions in a single database transaction.
+func (ix *index) store(ctx context.Context, bc complexity.BlockComplexity) error {
+	tx, err := ix.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, ix.query(
+		"INSERT INTO blocks (height, id, transactions, complexity, failed_transactions, failed_complexity, complexity_limit, utilization, timestamp, generator, size, base_target, fees) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"),
+		bc.Height, bc.ID.String(), len(bc.Transactions), bc.Complexity, bc.FailedTransactions, bc.FailedComplexity, bc.Limit, bc.Utilization,
+		int64(bc.Timestamp), bc.Generator.String(), int64(bc.Size), int64(bc.BaseTarget), int64(bc.Fees),
+	); err != nil {
+		return err
+	}
+	st, err := tx.PrepareContext(ctx, ix.query("INSERT INTO transactions (id, height, type, sender, status, complexity) VALUES (?, ?, ?, ?, ?, ?)"))
+	if err != nil {
+		return err
+	}
+	defer st.Close()
+	for _, c := range bc.Transactions {
+		sc := sql.NullInt64{Int64: int64(c.SpentComplexity), Valid: !c.Unknown}
+		if _, err := st.ExecContext(ctx, c.ID.String(), bc.Height, int(c.Type), c.Sender.String(), c.ApplicationStatus, sc); err != nil {
+			return errors.Wrapf(err, "transaction '%s'", c.ID.String())
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/alexeykiselev/waves-block-complexity/pkg/complexity"
+)
+
+const (
+	blockMeasurement       = "block_complexity"
+	transactionMeasurement = "tx_complexity"
+)
+
+// influxPrinter writes results in InfluxDB line protocol, a point of block_complexity measurement per block and
+// a point of tx_complexity measurement per transaction, timestamped by the block in nanoseconds. Points of
+// transactions of the same series would overwrite each other at the same timestamp, so the i-th transaction
+// of the block is timestamped i nanoseconds after the block.
+type influxPrinter struct {
+	w   *bufio.Writer
+	err error
+}
+
+func newInfluxPrinter(w io.Writer) *influxPrinter {
+	return &influxPrinter{w: bufio.NewWriter(w)}
+}
+
+func (p *influxPrinter) block(b complexity.BlockComplexity) error {
+	ts := b.Timestamp * 1_000_000
+	generator := b.Generator.String()
+	for i, c := range b.Transactions {
+		tags := "generator=" + escapeTag(generator) + ",type=" + escapeTag(complexity.TransactionTypeName(c.Type))
+		if c.Invocation != nil {
+			tags += ",dapp=" + escapeTag(c.Invocation.DApp)
+		}
+		// Unknown complexity is not written rather than written as zero
+		fields := fmt.Sprintf("complexity=%di", c.SpentComplexity)
+		if c.Unknown {
+			fields = "unknown=true"
+		}
+		p.printf("%s,%s %s,failed=%t,height=%di,id=\"%s\" %d\n",
+			transactionMeasurement, tags, fields, c.Failed(), b.Height, c.ID.String(), ts+uint64(i))
+	}
+	p.printf("%s,generator=%s complexity=%di,transactions=%di,failed_complexity=%di,failed_transactions=%di,limit=%di,utilization=%g,height=%di,size=%di,base_target=%di,fees=%di,unknown_transactions=%di,id=\"%s\" %d\n",
+		blockMeasurement, escapeTag(generator), b.Complexity, len(b.Transactions), b.FailedComplexity,
+		b.FailedTransactions, b.Limit, b.Utilization, b.Height, b.Size, b.BaseTarget, b.Fees, b.UnknownTransactions, b.ID.String(), ts)
+	if p.err != nil {
+		return p.err
+	}
+	return p.w.Flush()
+}
+
+func (p *influxPrinter) summary(b complexity.BlockComplexity) error {
+	return p.block(b)
+}
+
+// stats writes nothing, the aggregation of points is left to the database.
+func (p *influxPrinter) stats(complexity.RangeStats) error {
+	return nil
+}
+
+func (p *influxPrinter) flush() error {
+	if p.err != nil {
+		return p.err
+	}
+	return p.w.Flush()
+}
+
+func (p *influxPrinter) streaming() bool {
+	return true
+}
+
+// printf writes to the output remembering the first error.
+func (p *influxPrinter) printf(format string, args ...interface{}) {
+	if p.err != nil {
+		return
+	}
+	_, p.err = fmt.Fprintf(p.w, format, args...)
+}
+
+var tagEscaper = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `)
+
+// escapeTag escapes commas, equal signs and spaces in the tag value as the line protocol requires.
+func escapeTag(s string) string {
+	return tagEscaper.Replace(s)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/alexeykiselev/waves-block-complexity/pkg/complexity"
+	"github.com/pkg/errors"
+	"github.com/segmentio/kafka-go"
+)
+
+const kafkaWriteTimeout = 30 * time.Second
+
+// kafkaPrinter passes results to the underlying printer and publishes every analyzed block as a JSON message to
+// the Kafka topic, the message is keyed by the block ID. A block is passed to the underlying printer only after
+// the message is acknowledged by all in-sync replicas, so the processing stops if a block can't be published.
+// Writes are canceled with the context of the run.
+type kafkaPrinter struct {
+	printer
+	ctx context.Context
+	w   *kafka.Writer
+}
+
+func newKafkaPrinter(ctx context.Context, out printer, brokers []string, topic string) (*kafkaPrinter, error) {
+	if len(brokers) == 0 {
+		return nil, errors.New("no Kafka brokers")
+	}
+	if topic == "" {
+		return nil, errors.New("no Kafka topic")
+	}
+	w := &kafka.Writer{
+		Addr:         kafka.TCP(brokers...),
+		Topic:        topic,
+		Balancer:     &kafka.Hash{},
+		RequiredAcks: kafka.RequireAll,
+		BatchTimeout: 10 * time.Millisecond, // Messages are written one by one, there is nothing to wait for
+	}
+	return &kafkaPrinter{printer: out, ctx: ctx, w: w}, nil
+}
+
+func (p *kafkaPrinter) block(b complexity.BlockComplexity) error {
+	if err := p.publish(b); err != nil {
+		return err
+	}
+	return p.printer.block(b)
+}
+
+func (p *kafkaPrinter) summary(b complexity.BlockComplexity) error {
+	if err := p.publish(b); err != nil {
+		return err
+	}
+	return p.printer.summary(b)
+}
+
+func (p *kafkaPrinter) publish(b complexity.BlockComplexity) error {
+	value, err := json.Marshal(b)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(p.ctx, kafkaWriteTimeout)
+	defer cancel()
+	if err := p.w.WriteMessages(ctx, kafka.Message{Key: []byte(b.ID.String()), Value: value}); err != nil {
+		return errors.Wrapf(err, "failed to publish block %d to Kafka", b.Height)
+	}
+	return nil
+}
+
+func (p *kafkaPrinter) close() error {
+	return p.w.Close()
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/alexeykiselev/waves-block-complexity/pkg/complexity"
+	"github.com/pkg/errors"
+)
+
+const (
+	sortByPosition   = "position"
+	sortByComplexity = "complexity"
+	sortByID         = "id"
+	sortByType       = "type"
+)
+
+// listingOrder is the order of transactions in the listing.
+type listingOrder struct {
+	key  string
+	desc bool
+}
+
+// parseOrder parses the order given as key with optional direction, for example "complexity:asc".
+// Transactions are sorted by complexity in descending order and by other keys in ascending order by default.
+func parseOrder(s string) (listingOrder, error) {
+	key, dir, _ := strings.Cut(s, ":")
+	o := listingOrder{key: key}
+	switch key {
+	case "", sortByPosition:
+		o.key = sortByPosition
+	case sortByComplexity:
+		o.desc = true
+	case sortByID, sortByType:
+	default:
+		return listingOrder{}, errors.Errorf("unsupported sort key '%s'", key)
+	}
+	switch dir {
+	case "":
+	case "asc":
+		o.desc = false
+	case "desc":
+		o.desc = true
+	default:
+		return listingOrder{}, errors.Errorf("unsupported sort direction '%s'", dir)
+	}
+	return o, nil
+}
+
+// less compares the transactions by the key of the order in ascending order.
+func (o listingOrder) less(a, b complexity.Complexity) bool {
+	switch o.key {
+	case sortByComplexity:
+		return a.SpentComplexity < b.SpentComplexity
+	case sortByID:
+		return a.ID.String() < b.ID.String()
+	case sortByType:
+		return a.Type < b.Type
+	default:
+		return false
+	}
+}
+
+// listingPrinter filters, limits and orders the transactions of blocks passed to the underlying printer.
+// Totals and aggregations of blocks are not affected.
+type listingPrinter struct {
+	printer
+	top   int
+	min   int // Minimal complexity of a listed transaction
+	order listingOrder
+}
+
+func (p *listingPrinter) block(b complexity.BlockComplexity) error {
+	return p.printer.block(p.apply(b))
+}
+
+func (p *listingPrinter) summary(b complexity.BlockComplexity) error {
+	return p.printer.summary(p.apply(b))
+}
+
+// apply drops transactions below the minimal complexity, selects the given number of transactions with
+// the highest complexity, keeping their positions in the block, and sorts the transactions in the order of the listing.
+func (p *listingPrinter) apply(b complexity.BlockComplexity) complexity.BlockComplexity {
+	if p.top <= 0 && p.min <= 0 && p.order.key == sortByPosition && !p.order.desc {
+		return b
+	}
+	type positioned struct {
+		complexity.Complexity
+		pos int
+	}
+	txs := make([]positioned, 0, len(b.Transactions))
+	for i, tx := range b.Transactions {
+		if tx.SpentComplexity >= p.min {
+			txs = append(txs, positioned{tx, i})
+		}
+	}
+	if p.top > 0 && len(txs) > p.top {
+		sort.SliceStable(txs, func(i, j int) bool {
+			return txs[i].SpentComplexity > txs[j].SpentComplexity
+		})
+		txs = txs[:p.top]
+		sort.Slice(txs, func(i, j int) bool {
+			return txs[i].pos < txs[j].pos
+		})
+	}
+	sort.SliceStable(txs, func(i, j int) bool {
+		a, b := txs[i], txs[j]
+		if p.order.desc {
+			a, b = b, a
+		}
+		if p.order.key == sortByPosition {
+			return a.pos < b.pos
+		}
+		return p.order.less(a.Complexity, b.Complexity)
+	})
+	r := make([]complexity.Complexity, len(txs))
+	for i, tx := range txs {
+		r[i] = tx.Complexity
+	}
+	b.Transactions = r
+	return b
+}
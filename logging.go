@@ -0,0 +1,46 @@
+package main
+
+import (
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	textLogFormat = "text"
+	jsonLogFormat = "json"
+)
+
+// setupLogging configures the default logger writing diagnostic messages to stderr.
+// Debug messages are enabled if verbosity is set, all messages are discarded in quiet mode.
+func setupLogging(format string, verbosity int, quiet bool) error {
+	var w io.Writer = os.Stderr
+	if quiet {
+		w = io.Discard
+	}
+	opts := &slog.HandlerOptions{Level: slog.LevelInfo, ReplaceAttr: errorMessage}
+	if verbosity > 0 {
+		opts.Level = slog.LevelDebug
+	}
+	var h slog.Handler
+	switch format {
+	case "", textLogFormat:
+		h = slog.NewTextHandler(w, opts)
+	case jsonLogFormat:
+		h = slog.NewJSONHandler(w, opts)
+	default:
+		return errors.Errorf("unsupported log format '%s'", format)
+	}
+	slog.SetDefault(slog.New(h))
+	return nil
+}
+
+// errorMessage replaces errors with their messages, because text handler formats errors with stack traces.
+func errorMessage(_ []string, a slog.Attr) slog.Attr {
+	if err, ok := a.Value.Any().(error); ok {
+		a.Value = slog.StringValue(err.Error())
+	}
+	return a
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/alexeykiselev/waves-block-complexity/pkg/complexity"
+)
+
+// markdownPrinter writes results as GitHub-flavored Markdown tables.
+type markdownPrinter struct {
+	w      io.Writer
+	header bool // Header of the summaries table is written
+	err    error
+}
+
+func (p *markdownPrinter) block(b complexity.BlockComplexity) error {
+	p.printf("## Block %s at height %d\n\n", b.ID.String(), b.Height)
+	p.table([]string{"Transaction", "Type", "Status", "Complexity"}, transactionRows(b.Transactions))
+	rows := [][]string{
+		{"Time", blockTime(b)},
+		{"Generator", b.Generator.String()},
+		{"Size", fmt.Sprintf("%d bytes", b.Size)},
+		{"Base Target", strconv.FormatUint(b.BaseTarget, 10)},
+		{"Block Complexity", strconv.Itoa(b.Complexity)},
+		{"Succeeded Transactions Complexity", strconv.Itoa(b.Complexity - b.FailedComplexity)},
+		{"Failed Transactions Complexity", fmt.Sprintf("%d (%d transactions)", b.FailedComplexity, b.FailedTransactions)},
+	}
+	if b.UnknownTransactions > 0 {
+		rows = append(rows, []string{"Unknown Complexity", fmt.Sprintf("%d transactions, not counted", b.UnknownTransactions)})
+	}
+	if b.Limit > 0 {
+		rows = append(rows,
+			[]string{"Block Complexity Limit", strconv.Itoa(b.Limit)},
+			[]string{"Utilization", fmt.Sprintf("%.2f%%", b.Utilization)})
+	}
+	rows = append(rows, []string{"Fees", waves(b.Fees) + " WAVES"})
+	if len(b.Transactions) > 0 {
+		rows = append(rows, []string{"Transaction Complexity", distribution(b.Distribution)})
+	}
+	p.table([]string{"Metric", "Value"}, rows)
+	p.table([]string{"Type", "Transactions", "Complexity"}, typeRows(b.Types))
+	return p.err
+}
+
+func (p *markdownPrinter) summary(b complexity.BlockComplexity) error {
+	if !p.header {
+		p.row([]string{"Height", "Block", "Time", "Generator", "Size", "Base Target", "Transactions", "Complexity", "Utilization", "Fees, WAVES"})
+		p.row([]string{"---:", "---", "---", "---", "---:", "---:", "---:", "---:", "---:", "---:"})
+		p.header = true
+	}
+	p.row([]string{
+		strconv.FormatUint(b.Height, 10),
+		b.ID.String(),
+		blockTime(b),
+		b.Generator.String(),
+		strconv.FormatUint(b.Size, 10),
+		strconv.FormatUint(b.BaseTarget, 10),
+		strconv.Itoa(len(b.Transactions)),
+		strconv.Itoa(b.Complexity),
+		fmt.Sprintf("%.2f%%", b.Utilization),
+		waves(b.Fees),
+	})
+	return p.err
+}
+
+func (p *markdownPrinter) stats(s complexity.RangeStats) error {
+	p.printf("\n")
+	p.table([]string{"Metric", "Value"}, [][]string{
+		{"Blocks", strconv.FormatUint(s.Blocks, 10)},
+		{"Transactions", strconv.FormatUint(s.Transactions, 10)},
+		{"Total Complexity", strconv.Itoa(s.Complexity)},
+		{"Succeeded Transactions Complexity", strconv.Itoa(s.Complexity - s.FailedComplexity)},
+		{"Failed Transactions Complexity", fmt.Sprintf("%d (%d transactions)", s.FailedComplexity, s.FailedTransactions)},
+		{"Average Block Complexity", strconv.Itoa(s.AverageComplexity)},
+		{"Max Block Complexity", fmt.Sprintf("%d at height %d", s.MaxComplexity, s.MaxHeight)},
+		{"Average Utilization", fmt.Sprintf("%.2f%%", s.AverageUtilization)},
+		{"Max Utilization", fmt.Sprintf("%.2f%%", s.MaxUtilization)},
+		{"Block Complexity", distribution(s.Distribution)},
+		{"Transactions per Block", distribution(s.TransactionsDistribution)},
+	})
+	p.table([]string{"Type", "Transactions", "Complexity"}, typeRows(s.Types))
+	return p.err
+}
+
+func (p *markdownPrinter) flush() error {
+	return p.err
+}
+
+func (p *markdownPrinter) streaming() bool {
+	return true
+}
+
+// table writes the table followed by an empty line, nothing is written if there are no rows.
+func (p *markdownPrinter) table(header []string, rows [][]string) {
+	if len(rows) == 0 {
+		return
+	}
+	p.row(header)
+	sep := make([]string, len(header))
+	for i := range sep {
+		sep[i] = "---"
+	}
+	p.row(sep)
+	for _, r := range rows {
+		p.row(r)
+	}
+	p.printf("\n")
+}
+
+func (p *markdownPrinter) row(cells []string) {
+	escaped := make([]string, len(cells))
+	for i, c := range cells {
+		escaped[i] = strings.ReplaceAll(c, "|", `\|`)
+	}
+	p.printf("| %s |\n", strings.Join(escaped, " | "))
+}
+
+// printf writes to the output remembering the first error.
+func (p *markdownPrinter) printf(format string, args ...interface{}) {
+	if p.err != nil {
+		return
+	}
+	_, p.err = fmt.Fprintf(p.w, format, args...)
+}
+
+func transactionRows(txs []complexity.Complexity) [][]string {
+	rows := make([][]string, 0, len(txs))
+	for _, c := range txs {
+		rows = append(rows, []string{
+			c.ID.String(),
+			complexity.TransactionTypeName(c.Type),
+			c.ApplicationStatus,
+			spentComplexity(c),
+		})
+	}
+	return rows
+}
+
+func typeRows(types []complexity.TypeComplexity) [][]string {
+	rows := make([][]string, 0, len(types))
+	for _, t := range types {
+		rows = append(rows, []string{
+			complexity.TransactionTypeName(t.Type),
+			strconv.Itoa(t.Transactions),
+			strconv.Itoa(t.Complexity),
+		})
+	}
+	return rows
+}
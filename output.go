@@ -0,0 +1,524 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alexeykiselev/waves-block-complexity/pkg/complexity"
+	"github.com/pkg/errors"
+)
+
+const (
+	textFormat     = "text"
+	jsonFormat     = "json"
+	csvFormat      = "csv"
+	ndjsonFormat   = "ndjson"
+	templateFormat = "template"
+	markdownFormat = "markdown"
+	htmlFormat     = "html"
+	influxFormat   = "influx"
+	xlsxFormat     = "xlsx"
+	parquetFormat  = "parquet"
+	protoFormat    = "proto"
+)
+
+// printer formats the results of analysis.
+type printer interface {
+	// block prints the detailed complexity of a single block.
+	block(b complexity.BlockComplexity) error
+	// summary prints the complexity of one of many processed blocks.
+	summary(b complexity.BlockComplexity) error
+	// stats prints the aggregated complexity of a range of blocks.
+	stats(s complexity.RangeStats) error
+	// flush writes out everything buffered by the printer.
+	flush() error
+	// streaming reports whether the printer outputs results as they come, without buffering.
+	streaming() bool
+}
+
+// printerOptions configures the text and template printers.
+type printerOptions struct {
+	template    string // Text of the output template
+	senders     bool   // Print complexity aggregated by senders
+	histogram   bool   // Print histogram of transactions complexities
+	invocations bool   // Print trees of dApp calls
+	color       bool   // Color the output with ANSI escape sequences
+	highlight   int    // Complexity of a transaction to highlight, no highlighting if zero
+	transaction string // ID of the transaction to mark in the detailed output, no marking if empty
+	sizes       bool   // Print sizes and numbers of transactions of blocks next to their complexities
+	generators  bool   // Print the leaderboard of generators
+	dApps       bool   // Print the leaderboard of invoked dApps
+	window      int    // Number of blocks of the moving average of complexity printed with summaries, not printed if zero
+}
+
+func newPrinter(format string, w io.Writer, opts printerOptions) (printer, error) {
+	switch format {
+	case textFormat:
+		return &textPrinter{l: log.New(w, "", 0), opts: opts, c: palette(opts.color)}, nil
+	case jsonFormat:
+		return &jsonPrinter{w: w}, nil
+	case ndjsonFormat:
+		return &ndjsonPrinter{enc: json.NewEncoder(w)}, nil
+	case csvFormat:
+		return &csvPrinter{w: csv.NewWriter(w)}, nil
+	case markdownFormat:
+		return &markdownPrinter{w: w}, nil
+	case htmlFormat:
+		return &htmlPrinter{w: w}, nil
+	case influxFormat:
+		return newInfluxPrinter(w), nil
+	case xlsxFormat:
+		return &xlsxPrinter{w: w}, nil
+	case parquetFormat:
+		return newParquetPrinter(w), nil
+	case protoFormat:
+		return &protoPrinter{w: w}, nil
+	case templateFormat:
+		return newTemplatePrinter(w, opts.template)
+	default:
+		return nil, errors.New("unsupported format")
+	}
+}
+
+// textPrinter writes human-readable results.
+type textPrinter struct {
+	l      *log.Logger
+	opts   printerOptions
+	c      palette
+	recent []int // Complexities of the last blocks of the moving average window
+}
+
+func (p *textPrinter) block(b complexity.BlockComplexity) error {
+	for _, c := range b.Transactions {
+		marked := p.opts.transaction != "" && c.ID.String() == p.opts.transaction
+		if c.SpentComplexity > 0 || c.Unknown || marked {
+			sc := strconv.Itoa(c.SpentComplexity)
+			if c.Unknown {
+				sc = p.c.yellow("unknown")
+			} else if p.opts.highlight > 0 && c.SpentComplexity > p.opts.highlight {
+				sc = p.c.red(sc)
+			}
+			sc += scriptsComplexities(c)
+			line := fmt.Sprintf("[%s]\t%s\t%s", c.ID.String(), sc, feeRate(c))
+			if c.Failed() {
+				line += "\tfailed"
+			}
+			if p.underpriced(b, c) {
+				line += "\t" + p.c.yellow("underpriced")
+			}
+			if marked {
+				line += "\t" + p.c.bold("<- requested")
+			}
+			p.l.Print(line)
+			if p.opts.invocations && c.Invocation != nil {
+				p.printInvocation(*c.Invocation, 1)
+			}
+		}
+	}
+	p.l.Println()
+	p.l.Printf("Block %s at height %d forged by %s at %s", b.ID.String(), b.Height, b.Generator.String(), blockTime(b))
+	p.l.Printf("Size: %d bytes, Base Target: %d", b.Size, b.BaseTarget)
+	if b.Estimated {
+		p.l.Print(p.c.bold(fmt.Sprintf("Estimated Block Complexity: %d", b.Complexity)))
+	} else {
+		p.l.Print(p.c.bold(fmt.Sprintf("Block Complexity: %d", b.Complexity)))
+	}
+	if b.Note != "" {
+		p.l.Print(b.Note)
+	}
+	p.l.Printf("Succeeded Transactions Complexity: %d", b.Complexity-b.FailedComplexity)
+	p.l.Printf("Failed Transactions Complexity: %d (%d transactions)", b.FailedComplexity, b.FailedTransactions)
+	if b.UnknownTransactions > 0 {
+		p.l.Printf("Unknown Complexity: %d transactions, not counted", b.UnknownTransactions)
+	}
+	if b.VerifierComplexity > 0 {
+		p.l.Printf("Verifiers Complexity: %d", b.VerifierComplexity)
+	}
+	if b.AssetsComplexity > 0 {
+		p.l.Printf("Smart Assets Complexity: %d", b.AssetsComplexity)
+	}
+	if b.Limit > 0 {
+		p.l.Printf("Block Complexity Limit: %d", b.Limit)
+		p.l.Printf("Utilization: %s", p.c.utilization(b.Utilization))
+	}
+	p.l.Printf("Fees: %s WAVES (%.1f wavelets per unit of complexity)", waves(b.Fees), b.FeePerComplexity)
+	if len(b.Transactions) > 0 {
+		p.l.Printf("Transaction Complexity: %s", distribution(b.Distribution))
+	}
+	p.printTypes(b.Types)
+	p.printAssets(b.Assets)
+	if p.opts.senders {
+		p.printSenders(b.Senders)
+	}
+	if p.opts.histogram {
+		p.printHistogram(b.Histogram)
+	}
+	return nil
+}
+
+func (p *textPrinter) summary(b complexity.BlockComplexity) error {
+	line := fmt.Sprintf("[%d]\t%s\t%s\t%s\t%d\t%d\t%s\t%d\t%s WAVES", b.Height, blockTime(b), b.ID.String(), b.Generator.String(),
+		len(b.Transactions), b.Complexity, p.c.utilization(b.Utilization), b.BaseTarget, waves(b.Fees))
+	if p.opts.window > 0 {
+		line += fmt.Sprintf("\tavg %.0f", p.movingAverage(b.Complexity))
+	}
+	if p.opts.sizes {
+		line += fmt.Sprintf("\t%d bytes\t%d txs\t%.1f/KB\t%.1f/tx", b.Size, b.TransactionCount,
+			ratio(b.Complexity, float64(b.Size)/1024), ratio(b.Complexity, float64(b.TransactionCount)))
+	}
+	p.l.Print(line)
+	return nil
+}
+
+// movingAverage adds the complexity to the window and returns the average complexity of the blocks within it,
+// the window is partial until enough blocks are processed.
+func (p *textPrinter) movingAverage(c int) float64 {
+	p.recent = append(p.recent, c)
+	if len(p.recent) > p.opts.window {
+		p.recent = p.recent[1:]
+	}
+	sum := 0
+	for _, v := range p.recent {
+		sum += v
+	}
+	return float64(sum) / float64(len(p.recent))
+}
+
+func (p *textPrinter) stats(s complexity.RangeStats) error {
+	p.l.Println()
+	p.l.Printf("Blocks: %d", s.Blocks)
+	p.l.Printf("Transactions: %d", s.Transactions)
+	p.l.Print(p.c.bold(fmt.Sprintf("Total Complexity: %d", s.Complexity)))
+	p.l.Printf("Succeeded Transactions Complexity: %d", s.Complexity-s.FailedComplexity)
+	p.l.Printf("Failed Transactions Complexity: %d (%d transactions)", s.FailedComplexity, s.FailedTransactions)
+	if s.UnknownTransactions > 0 {
+		p.l.Printf("Unknown Complexity: %d transactions, not counted", s.UnknownTransactions)
+	}
+	if s.VerifierComplexity > 0 {
+		p.l.Printf("Verifiers Complexity: %d", s.VerifierComplexity)
+	}
+	if s.AssetsComplexity > 0 {
+		p.l.Printf("Smart Assets Complexity: %d", s.AssetsComplexity)
+	}
+	p.l.Printf("Average Block Complexity: %d", s.AverageComplexity)
+	p.l.Printf("Max Block Complexity: %d at height %d", s.MaxComplexity, s.MaxHeight)
+	p.l.Printf("Average Utilization: %s", p.c.utilization(s.AverageUtilization))
+	p.l.Printf("Max Utilization: %s", p.c.utilization(s.MaxUtilization))
+	p.l.Printf("Block Complexity: %s", distribution(s.Distribution))
+	p.l.Printf("Transactions per Block: %s", distribution(s.TransactionsDistribution))
+	if p.opts.sizes {
+		p.l.Printf("Average Block Size: %d bytes", s.AverageSize)
+		p.l.Printf("Correlation of Complexity with Block Size: %.2f", s.SizeCorrelation)
+		p.l.Printf("Correlation of Complexity with Transactions Count: %.2f", s.CountCorrelation)
+	}
+	p.printTypes(s.Types)
+	p.printAssets(s.Assets)
+	if p.opts.senders {
+		p.printSenders(s.Senders)
+	}
+	if p.opts.generators {
+		p.printGenerators(s.Generators)
+	}
+	if p.opts.dApps {
+		p.printDApps(s.DApps, s.Complexity)
+	}
+	if p.opts.histogram {
+		p.printHistogram(s.Histogram)
+	}
+	return nil
+}
+
+// underpriced reports whether the transaction is a heavy invocation, which complexity exceeds the highlighted one,
+// paying less fee per unit of complexity than the block on average.
+func (p *textPrinter) underpriced(b complexity.BlockComplexity, c complexity.Complexity) bool {
+	return c.Invocation != nil && p.opts.highlight > 0 && c.SpentComplexity > p.opts.highlight &&
+		c.FeeAssetID == "" && c.FeePerComplexity() < b.FeePerComplexity
+}
+
+// spentComplexity formats the complexity spent by the transaction, "unknown" if the node didn't report it.
+func spentComplexity(c complexity.Complexity) string {
+	if c.Unknown {
+		return "unknown"
+	}
+	return strconv.Itoa(c.SpentComplexity)
+}
+
+// feeRate formats the fee paid by the transaction per unit of spent complexity.
+func feeRate(c complexity.Complexity) string {
+	if c.FeeAssetID != "" {
+		return "sponsored fee"
+	}
+	if c.Unknown {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f/unit", c.FeePerComplexity())
+}
+
+// waves formats the amount in wavelets as WAVES.
+func waves(amount uint64) string {
+	return fmt.Sprintf("%d.%08d", amount/1e8, amount%1e8)
+}
+
+// blockTime returns the time of creation of the block in UTC as RFC3339 timestamp.
+func blockTime(b complexity.BlockComplexity) string {
+	return time.UnixMilli(int64(b.Timestamp)).UTC().Format(time.RFC3339)
+}
+
+// ratio returns the quotient of the values, zero if the divisor is zero.
+func ratio(a int, b float64) float64 {
+	if b == 0 {
+		return 0
+	}
+	return float64(a) / b
+}
+
+// distribution formats the distribution of values on a single line.
+func distribution(d complexity.Distribution) string {
+	return fmt.Sprintf("mean %.1f, median %d, p90 %d, p95 %d, max %d", d.Mean, d.Median, d.P90, d.P95, d.Max)
+}
+
+func (p *textPrinter) printTypes(types []complexity.TypeComplexity) {
+	if len(types) == 0 {
+		return
+	}
+	p.l.Println()
+	p.l.Printf("Complexity by Transaction Type:")
+	for _, t := range types {
+		p.l.Printf("%s\t%d\t%d", complexity.TransactionTypeName(t.Type), t.Transactions, t.Complexity)
+	}
+}
+
+func (p *textPrinter) printInvocation(inv complexity.Invocation, depth int) {
+	p.l.Printf("%s%s", strings.Repeat("  ", depth), invocation(inv))
+	for _, c := range inv.Invocations {
+		p.printInvocation(c, depth+1)
+	}
+}
+
+// invocation formats the called function of the dApp with versions of its script and the estimated complexity
+// if they are known.
+func invocation(inv complexity.Invocation) string {
+	s := inv.DApp + "." + inv.Function
+	if inv.RideVersion > 0 {
+		s += fmt.Sprintf(" (Ride V%d, estimator V%d)", inv.RideVersion, inv.Estimator)
+	}
+	if inv.Complexity > 0 {
+		s += fmt.Sprintf(", estimated complexity %d", inv.Complexity)
+	}
+	return s
+}
+
+func (p *textPrinter) printAssets(assets []complexity.AssetComplexity) {
+	if len(assets) == 0 {
+		return
+	}
+	p.l.Println()
+	p.l.Printf("Complexity by Smart Asset:")
+	for _, a := range assets {
+		p.l.Printf("%s\t%d\t%d", a.Asset.String(), a.Transactions, a.Complexity)
+	}
+}
+
+// scriptsComplexities formats the parts of the transaction's complexity charged for the verifier and smart assets
+// if they are known.
+func scriptsComplexities(c complexity.Complexity) string {
+	var parts []string
+	if c.VerifierComplexity > 0 {
+		parts = append(parts, fmt.Sprintf("verifier %d", c.VerifierComplexity))
+	}
+	if ac := c.AssetsComplexity(); ac > 0 {
+		parts = append(parts, fmt.Sprintf("assets %d", ac))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(parts, ", ") + ")"
+}
+
+func (p *textPrinter) printSenders(senders []complexity.SenderComplexity) {
+	if len(senders) == 0 {
+		return
+	}
+	p.l.Println()
+	p.l.Printf("Complexity by Sender:")
+	for _, s := range senders {
+		p.l.Printf("%s\t%d\t%d", s.Sender.String(), s.Transactions, s.Complexity)
+	}
+}
+
+func (p *textPrinter) printGenerators(generators []complexity.GeneratorComplexity) {
+	if len(generators) == 0 {
+		return
+	}
+	p.l.Println()
+	p.l.Printf("Generators by Average Block Complexity:")
+	for _, g := range generators {
+		p.l.Printf("%s\t%d\t%d\t%d\t%s", g.Generator.String(), g.Blocks, g.Complexity, g.AverageComplexity, p.c.utilization(g.AverageUtilization))
+	}
+}
+
+// printDApps prints the complexity spent by invocations of dApps with their shares of the total complexity.
+func (p *textPrinter) printDApps(dApps []complexity.DAppComplexity, total int) {
+	if len(dApps) == 0 {
+		return
+	}
+	p.l.Println()
+	p.l.Printf("Complexity by dApp:")
+	for i, d := range dApps {
+		p.l.Printf("%d.\t%s\t%d\t%d\t%.2f%%", i+1, d.DApp, d.Transactions, d.Complexity, ratio(d.Complexity*100, float64(total)))
+	}
+}
+
+func (p *textPrinter) printHistogram(buckets []complexity.Bucket) {
+	p.l.Println()
+	p.l.Printf("Histogram of Transaction Complexity:")
+	for _, b := range buckets {
+		p.l.Printf("%s\t%d\t%d", b.Range, b.Transactions, b.Complexity)
+	}
+}
+
+func (p *textPrinter) flush() error {
+	return nil
+}
+
+func (p *textPrinter) streaming() bool {
+	return true
+}
+
+// jsonPrinter collects results and writes them as a single JSON document.
+type jsonPrinter struct {
+	w      io.Writer
+	single *complexity.BlockComplexity
+	blocks []complexity.BlockComplexity
+	st     *complexity.RangeStats
+}
+
+func (p *jsonPrinter) block(b complexity.BlockComplexity) error {
+	p.single = &b
+	return nil
+}
+
+func (p *jsonPrinter) summary(b complexity.BlockComplexity) error {
+	p.blocks = append(p.blocks, b)
+	return nil
+}
+
+func (p *jsonPrinter) stats(s complexity.RangeStats) error {
+	p.st = &s
+	return nil
+}
+
+func (p *jsonPrinter) flush() error {
+	enc := json.NewEncoder(p.w)
+	enc.SetIndent("", "  ")
+	if p.single != nil {
+		return enc.Encode(p.single)
+	}
+	doc := struct {
+		Blocks []complexity.BlockComplexity `json:"blocks"`
+		Stats  *complexity.RangeStats       `json:"stats,omitempty"`
+	}{
+		Blocks: p.blocks,
+		Stats:  p.st,
+	}
+	if doc.Blocks == nil {
+		doc.Blocks = []complexity.BlockComplexity{}
+	}
+	return enc.Encode(doc)
+}
+
+func (p *jsonPrinter) streaming() bool {
+	return false
+}
+
+// ndjsonPrinter writes every processed block as a separate line of JSON as soon as it is completed.
+type ndjsonPrinter struct {
+	enc *json.Encoder
+}
+
+func (p *ndjsonPrinter) block(b complexity.BlockComplexity) error {
+	return p.enc.Encode(b)
+}
+
+func (p *ndjsonPrinter) summary(b complexity.BlockComplexity) error {
+	return p.enc.Encode(b)
+}
+
+func (p *ndjsonPrinter) stats(s complexity.RangeStats) error {
+	return p.enc.Encode(struct {
+		Stats complexity.RangeStats `json:"stats"`
+	}{Stats: s})
+}
+
+func (p *ndjsonPrinter) flush() error {
+	return nil
+}
+
+func (p *ndjsonPrinter) streaming() bool {
+	return true
+}
+
+// csvPrinter writes one row per transaction of every processed block.
+type csvPrinter struct {
+	w      *csv.Writer
+	header bool
+}
+
+func (p *csvPrinter) block(b complexity.BlockComplexity) error {
+	if !p.header {
+		if err := p.w.Write([]string{"block", "height", "transaction", "type", "status", "complexity", "fee", "fee_asset",
+			"block_timestamp", "generator", "block_size", "base_target", "block_fees"}); err != nil {
+			return err
+		}
+		p.header = true
+	}
+	for _, c := range b.Transactions {
+		sc := strconv.Itoa(c.SpentComplexity)
+		if c.Unknown {
+			sc = "" // Unknown complexity is left empty rather than counted as zero
+		}
+		row := []string{
+			b.ID.String(),
+			strconv.FormatUint(b.Height, 10),
+			c.ID.String(),
+			complexity.TransactionTypeName(c.Type),
+			c.ApplicationStatus,
+			sc,
+			strconv.FormatUint(c.Fee, 10),
+			c.FeeAssetID,
+			strconv.FormatUint(b.Timestamp, 10),
+			b.Generator.String(),
+			strconv.FormatUint(b.Size, 10),
+			strconv.FormatUint(b.BaseTarget, 10),
+			strconv.FormatUint(b.Fees, 10),
+		}
+		if err := p.w.Write(row); err != nil {
+			return err
+		}
+	}
+	p.w.Flush()
+	return p.w.Error()
+}
+
+func (p *csvPrinter) summary(b complexity.BlockComplexity) error {
+	return p.block(b)
+}
+
+func (p *csvPrinter) stats(complexity.RangeStats) error {
+	return nil
+}
+
+func (p *csvPrinter) flush() error {
+	p.w.Flush()
+	return p.w.Error()
+}
+
+func (p *csvPrinter) streaming() bool {
+	return true
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"io"
+
+	"github.com/alexeykiselev/waves-block-complexity/pkg/complexity"
+	"github.com/parquet-go/parquet-go"
+)
+
+// parquetRowGroupSize is the number of transactions in a row group of the Parquet file.
+const parquetRowGroupSize = 100000
+
+// parquetRecord is the row of the Parquet file with the complexity of a transaction.
+type parquetRecord struct {
+	Height             int64   `parquet:"height"`
+	Block              string  `parquet:"block"`
+	Timestamp          int64   `parquet:"timestamp,timestamp(millisecond)"`
+	Generator          string  `parquet:"generator,dict"`
+	Transaction        string  `parquet:"transaction"`
+	Type               string  `parquet:"type,dict"`
+	Sender             string  `parquet:"sender,dict"`
+	Status             string  `parquet:"status,dict"`
+	Complexity         *int64  `parquet:"complexity,optional"` // Null if the node didn't report the spent complexity
+	VerifierComplexity int64   `parquet:"verifier_complexity"`
+	AssetsComplexity   int64   `parquet:"assets_complexity"`
+	Fee                int64   `parquet:"fee"`
+	FeeAsset           *string `parquet:"fee_asset,optional,dict"`
+	DApp               *string `parquet:"dapp,optional,dict"`
+	Function           *string `parquet:"function,optional,dict"`
+	BlockSize          int64   `parquet:"block_size"`
+	BaseTarget         int64   `parquet:"base_target"`
+	BlockFees          int64   `parquet:"block_fees"` // Fees paid in WAVES by the analyzed transactions of the block
+}
+
+// parquetPrinter writes one ZSTD compressed row per transaction of every processed block to the Parquet file,
+// the footer of the file is written on flush. Aggregated statistics are not written.
+type parquetPrinter struct {
+	w    *parquet.GenericWriter[parquetRecord]
+	rows int // Rows written since the last row group was completed
+}
+
+func newParquetPrinter(w io.Writer) *parquetPrinter {
+	return &parquetPrinter{w: parquet.NewGenericWriter[parquetRecord](w, parquet.Compression(&parquet.Zstd))}
+}
+
+func (p *parquetPrinter) block(b complexity.BlockComplexity) error {
+	rows := make([]parquetRecord, len(b.Transactions))
+	for i, c := range b.Transactions {
+		rows[i] = parquetRecord{
+			Height:             int64(b.Height),
+			Block:              b.ID.String(),
+			Timestamp:          int64(b.Timestamp),
+			Generator:          b.Generator.String(),
+			Transaction:        c.ID.String(),
+			Type:               complexity.TransactionTypeName(c.Type),
+			Sender:             c.Sender.String(),
+			Status:             c.ApplicationStatus,
+			VerifierComplexity: int64(c.VerifierComplexity),
+			AssetsComplexity:   int64(c.AssetsComplexity()),
+			Fee:                int64(c.Fee),
+			BlockSize:          int64(b.Size),
+			BaseTarget:         int64(b.BaseTarget),
+			BlockFees:          int64(b.Fees),
+		}
+		if !c.Unknown {
+			sc := int64(c.SpentComplexity)
+			rows[i].Complexity = &sc
+		}
+		if c.FeeAssetID != "" {
+			asset := c.FeeAssetID
+			rows[i].FeeAsset = &asset
+		}
+		if inv := c.Invocation; inv != nil {
+			rows[i].DApp, rows[i].Function = &inv.DApp, &inv.Function
+		}
+	}
+	if _, err := p.w.Write(rows); err != nil {
+		return err
+	}
+	if p.rows += len(rows); p.rows >= parquetRowGroupSize {
+		p.rows = 0
+		return p.w.Flush()
+	}
+	return nil
+}
+
+func (p *parquetPrinter) summary(b complexity.BlockComplexity) error {
+	return p.block(b)
+}
+
+func (p *parquetPrinter) stats(complexity.RangeStats) error {
+	return nil
+}
+
+func (p *parquetPrinter) flush() error {
+	return p.w.Close()
+}
+
+func (p *parquetPrinter) streaming() bool {
+	return false
+}
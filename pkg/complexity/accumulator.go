@@ -0,0 +1,170 @@
+package complexity
+
+import (
+	"encoding/json"
+
+	"github.com/wavesplatform/gowaves/pkg/crypto"
+	"github.com/wavesplatform/gowaves/pkg/proto"
+)
+
+// Accumulator aggregates complexities of blocks of a range. Its state can be saved as JSON and restored
+// to continue the aggregation later.
+type Accumulator struct {
+	// Last is the height of the last processed block of the range, including the skipped blocks.
+	Last uint64
+
+	st           RangeStats
+	types        map[proto.TransactionType]*TypeComplexity
+	senders      map[proto.WavesAddress]*SenderComplexity
+	assets       map[crypto.Digest]*AssetComplexity
+	generators   map[proto.WavesAddress]*GeneratorComplexity
+	dApps        map[string]*DAppComplexity
+	complexities []int
+	transactions []int
+	sizes        []int
+	counts       []int // Numbers of all transactions of blocks
+	size         uint64
+	utilization  float64
+}
+
+// NewAccumulator creates the empty accumulator.
+func NewAccumulator() *Accumulator {
+	return &Accumulator{
+		st:         RangeStats{Histogram: newHistogram()},
+		types:      make(map[proto.TransactionType]*TypeComplexity),
+		senders:    make(map[proto.WavesAddress]*SenderComplexity),
+		assets:     make(map[crypto.Digest]*AssetComplexity),
+		generators: make(map[proto.WavesAddress]*GeneratorComplexity),
+		dApps:      make(map[string]*DAppComplexity),
+	}
+}
+
+// Add adds the complexity of the block to the aggregates.
+func (a *Accumulator) Add(bc BlockComplexity) {
+	a.st.Blocks++
+	a.st.Transactions += uint64(len(bc.Transactions))
+	a.complexities = append(a.complexities, bc.Complexity)
+	a.transactions = append(a.transactions, len(bc.Transactions))
+	a.sizes = append(a.sizes, int(bc.Size))
+	a.counts = append(a.counts, bc.TransactionCount)
+	a.size += bc.Size
+	for _, t := range bc.Types {
+		addType(a.types, t.Type, t.Transactions, t.Complexity)
+	}
+	for _, sc := range bc.Senders {
+		addSender(a.senders, sc.Sender, sc.Transactions, sc.Complexity)
+	}
+	for _, ac := range bc.Assets {
+		addAsset(a.assets, ac.Asset, ac.Transactions, ac.Complexity)
+	}
+	for _, dc := range bc.DApps() {
+		addDApp(a.dApps, dc.DApp, dc.Transactions, dc.Complexity)
+	}
+	addGenerator(a.generators, bc.Generator, 1, bc.Complexity, bc.Utilization)
+	addHistogram(a.st.Histogram, bc.Histogram)
+	a.st.Complexity += bc.Complexity
+	a.st.FailedTransactions += uint64(bc.FailedTransactions)
+	a.st.FailedComplexity += bc.FailedComplexity
+	a.st.UnknownTransactions += uint64(bc.UnknownTransactions)
+	a.st.VerifierComplexity += bc.VerifierComplexity
+	a.st.AssetsComplexity += bc.AssetsComplexity
+	if bc.Complexity > a.st.MaxComplexity || a.st.MaxHeight == 0 {
+		a.st.MaxComplexity = bc.Complexity
+		a.st.MaxHeight = bc.Height
+	}
+	if bc.Utilization > a.st.MaxUtilization {
+		a.st.MaxUtilization = bc.Utilization
+	}
+	a.utilization += bc.Utilization
+}
+
+// Stats returns the aggregated complexity of the added blocks.
+func (a *Accumulator) Stats() RangeStats {
+	st := a.st
+	st.Histogram = append([]Bucket(nil), a.st.Histogram...)
+	if st.Blocks > 0 {
+		st.AverageComplexity = st.Complexity / int(st.Blocks)
+		st.AverageUtilization = a.utilization / float64(st.Blocks)
+		st.AverageSize = a.size / st.Blocks
+	}
+	st.Types = sortTypes(a.types)
+	st.Senders = sortSenders(a.senders)
+	st.Assets = sortAssets(a.assets)
+	st.Generators = sortGenerators(a.generators)
+	st.DApps = sortDApps(a.dApps)
+	st.Distribution = newDistribution(a.complexities)
+	st.TransactionsDistribution = newDistribution(a.transactions)
+	// Blocks added before the state was saved by a version without sizes are not correlated
+	if len(a.sizes) == len(a.complexities) {
+		st.SizeCorrelation = correlation(a.complexities, a.sizes)
+		st.CountCorrelation = correlation(a.complexities, a.counts)
+	}
+	return st
+}
+
+type accumulatorState struct {
+	Last         uint64     `json:"last"`
+	Stats        RangeStats `json:"stats"`
+	Complexities []int      `json:"complexities"`
+	Transactions []int      `json:"transactions"`
+	Sizes        []int      `json:"sizes,omitempty"`
+	Counts       []int      `json:"counts,omitempty"`
+	Size         uint64     `json:"size,omitempty"`
+	Utilization  float64    `json:"utilization"`
+}
+
+func (a *Accumulator) MarshalJSON() ([]byte, error) {
+	st := a.st
+	st.Types = sortTypes(a.types)
+	st.Senders = sortSenders(a.senders)
+	st.Assets = sortAssets(a.assets)
+	st.Generators = sortGenerators(a.generators)
+	st.DApps = sortDApps(a.dApps)
+	return json.Marshal(accumulatorState{
+		Last:         a.Last,
+		Stats:        st,
+		Complexities: a.complexities,
+		Transactions: a.transactions,
+		Sizes:        a.sizes,
+		Counts:       a.counts,
+		Size:         a.size,
+		Utilization:  a.utilization,
+	})
+}
+
+func (a *Accumulator) UnmarshalJSON(data []byte) error {
+	var s accumulatorState
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	*a = *NewAccumulator()
+	for _, t := range s.Stats.Types {
+		addType(a.types, t.Type, t.Transactions, t.Complexity)
+	}
+	for _, sc := range s.Stats.Senders {
+		addSender(a.senders, sc.Sender, sc.Transactions, sc.Complexity)
+	}
+	for _, ac := range s.Stats.Assets {
+		addAsset(a.assets, ac.Asset, ac.Transactions, ac.Complexity)
+	}
+	for _, dc := range s.Stats.DApps {
+		addDApp(a.dApps, dc.DApp, dc.Transactions, dc.Complexity)
+	}
+	for _, gc := range s.Stats.Generators {
+		addGenerator(a.generators, gc.Generator, gc.Blocks, gc.Complexity, gc.AverageUtilization*float64(gc.Blocks))
+	}
+	if len(s.Stats.Histogram) == len(a.st.Histogram) {
+		addHistogram(a.st.Histogram, s.Stats.Histogram)
+	}
+	s.Stats.Types, s.Stats.Senders, s.Stats.Assets, s.Stats.Generators, s.Stats.DApps = nil, nil, nil, nil, nil
+	s.Stats.Histogram = a.st.Histogram
+	a.Last = s.Last
+	a.st = s.Stats
+	a.complexities = s.Complexities
+	a.transactions = s.Transactions
+	a.sizes = s.Sizes
+	a.counts = s.Counts
+	a.size = s.Size
+	a.utilization = s.Utilization
+	return nil
+}
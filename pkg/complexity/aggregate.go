@@ -0,0 +1,260 @@
+package complexity
+
+import (
+	"sort"
+
+	"github.com/wavesplatform/gowaves/pkg/crypto"
+	"github.com/wavesplatform/gowaves/pkg/proto"
+)
+
+// TypeComplexity is the complexity spent by all transactions of the same type.
+type TypeComplexity struct {
+	Type         proto.TransactionType `json:"type"`
+	Transactions int                   `json:"transactions"`
+	Complexity   int                   `json:"complexity"`
+}
+
+// SenderComplexity is the complexity spent by all transactions of the same sender.
+type SenderComplexity struct {
+	Sender       proto.WavesAddress `json:"sender"`
+	Transactions int                `json:"transactions"`
+	Complexity   int                `json:"complexity"`
+}
+
+// typesComplexities aggregates complexities by transaction type, the result is sorted by complexity descending.
+func typesComplexities(complexities []Complexity) []TypeComplexity {
+	m := make(map[proto.TransactionType]*TypeComplexity)
+	for _, c := range complexities {
+		addType(m, c.Type, 1, c.SpentComplexity)
+	}
+	return sortTypes(m)
+}
+
+func addType(m map[proto.TransactionType]*TypeComplexity, t proto.TransactionType, transactions, complexity int) {
+	tc, ok := m[t]
+	if !ok {
+		tc = &TypeComplexity{Type: t}
+		m[t] = tc
+	}
+	tc.Transactions += transactions
+	tc.Complexity += complexity
+}
+
+func sortTypes(m map[proto.TransactionType]*TypeComplexity) []TypeComplexity {
+	r := make([]TypeComplexity, 0, len(m))
+	for _, tc := range m {
+		r = append(r, *tc)
+	}
+	sort.Slice(r, func(i, j int) bool {
+		if r[i].Complexity != r[j].Complexity {
+			return r[i].Complexity > r[j].Complexity
+		}
+		return r[i].Type < r[j].Type
+	})
+	return r
+}
+
+// sendersComplexities aggregates complexities by transaction sender, the result is sorted by complexity descending.
+func sendersComplexities(complexities []Complexity) []SenderComplexity {
+	m := make(map[proto.WavesAddress]*SenderComplexity)
+	for _, c := range complexities {
+		addSender(m, c.Sender, 1, c.SpentComplexity)
+	}
+	return sortSenders(m)
+}
+
+func addSender(m map[proto.WavesAddress]*SenderComplexity, a proto.WavesAddress, transactions, complexity int) {
+	sc, ok := m[a]
+	if !ok {
+		sc = &SenderComplexity{Sender: a}
+		m[a] = sc
+	}
+	sc.Transactions += transactions
+	sc.Complexity += complexity
+}
+
+func sortSenders(m map[proto.WavesAddress]*SenderComplexity) []SenderComplexity {
+	r := make([]SenderComplexity, 0, len(m))
+	for _, sc := range m {
+		r = append(r, *sc)
+	}
+	sort.Slice(r, func(i, j int) bool {
+		if r[i].Complexity != r[j].Complexity {
+			return r[i].Complexity > r[j].Complexity
+		}
+		return r[i].Sender.String() < r[j].Sender.String()
+	})
+	return r
+}
+
+// GeneratorComplexity is the complexity of all blocks forged by the same generator.
+type GeneratorComplexity struct {
+	Generator          proto.WavesAddress `json:"generator"`
+	Blocks             int                `json:"blocks"`
+	Complexity         int                `json:"complexity"`
+	AverageComplexity  int                `json:"averageComplexity"`
+	AverageUtilization float64            `json:"averageUtilization"`
+
+	utilization float64 // Total utilization of the blocks
+}
+
+func addGenerator(m map[proto.WavesAddress]*GeneratorComplexity, a proto.WavesAddress, blocks, complexity int, utilization float64) {
+	gc, ok := m[a]
+	if !ok {
+		gc = &GeneratorComplexity{Generator: a}
+		m[a] = gc
+	}
+	gc.Blocks += blocks
+	gc.Complexity += complexity
+	gc.utilization += utilization
+	gc.AverageComplexity = gc.Complexity / gc.Blocks
+	gc.AverageUtilization = gc.utilization / float64(gc.Blocks)
+}
+
+// sortGenerators returns the leaderboard of generators sorted by average complexity of their blocks descending.
+func sortGenerators(m map[proto.WavesAddress]*GeneratorComplexity) []GeneratorComplexity {
+	r := make([]GeneratorComplexity, 0, len(m))
+	for _, gc := range m {
+		r = append(r, *gc)
+	}
+	sort.Slice(r, func(i, j int) bool {
+		if r[i].AverageComplexity != r[j].AverageComplexity {
+			return r[i].AverageComplexity > r[j].AverageComplexity
+		}
+		return r[i].Generator.String() < r[j].Generator.String()
+	})
+	return r
+}
+
+// DAppComplexity is the complexity spent by all transactions calling the same dApp.
+type DAppComplexity struct {
+	DApp         string `json:"dApp"`
+	Transactions int    `json:"transactions"`
+	Complexity   int    `json:"complexity"`
+}
+
+// dAppsComplexities aggregates complexities of InvokeScript transactions by the called dApp, nested calls are
+// not taken into account. The result is sorted by complexity descending.
+func dAppsComplexities(complexities []Complexity) []DAppComplexity {
+	m := make(map[string]*DAppComplexity)
+	for _, c := range complexities {
+		if c.Invocation != nil {
+			addDApp(m, c.Invocation.DApp, 1, c.SpentComplexity)
+		}
+	}
+	return sortDApps(m)
+}
+
+func addDApp(m map[string]*DAppComplexity, dApp string, transactions, complexity int) {
+	dc, ok := m[dApp]
+	if !ok {
+		dc = &DAppComplexity{DApp: dApp}
+		m[dApp] = dc
+	}
+	dc.Transactions += transactions
+	dc.Complexity += complexity
+}
+
+func sortDApps(m map[string]*DAppComplexity) []DAppComplexity {
+	r := make([]DAppComplexity, 0, len(m))
+	for _, dc := range m {
+		r = append(r, *dc)
+	}
+	sort.Slice(r, func(i, j int) bool {
+		if r[i].Complexity != r[j].Complexity {
+			return r[i].Complexity > r[j].Complexity
+		}
+		return r[i].DApp < r[j].DApp
+	})
+	return r
+}
+
+// SmartAsset is the complexity of the smart asset's script run by a transaction.
+type SmartAsset struct {
+	Asset      crypto.Digest `json:"asset"`
+	Complexity int           `json:"complexity"`
+}
+
+// AssetComplexity is the complexity of the smart asset's script run by all transactions moving the asset.
+type AssetComplexity struct {
+	Asset        crypto.Digest `json:"asset"`
+	Transactions int           `json:"transactions"`
+	Complexity   int           `json:"complexity"`
+}
+
+// assetsComplexities aggregates complexities of scripts of smart assets, the result is sorted by complexity
+// descending.
+func assetsComplexities(complexities []Complexity) []AssetComplexity {
+	m := make(map[crypto.Digest]*AssetComplexity)
+	for _, c := range complexities {
+		for _, sa := range c.SmartAssets {
+			addAsset(m, sa.Asset, 1, sa.Complexity)
+		}
+	}
+	return sortAssets(m)
+}
+
+func addAsset(m map[crypto.Digest]*AssetComplexity, id crypto.Digest, transactions, complexity int) {
+	ac, ok := m[id]
+	if !ok {
+		ac = &AssetComplexity{Asset: id}
+		m[id] = ac
+	}
+	ac.Transactions += transactions
+	ac.Complexity += complexity
+}
+
+func sortAssets(m map[crypto.Digest]*AssetComplexity) []AssetComplexity {
+	r := make([]AssetComplexity, 0, len(m))
+	for _, ac := range m {
+		r = append(r, *ac)
+	}
+	sort.Slice(r, func(i, j int) bool {
+		if r[i].Complexity != r[j].Complexity {
+			return r[i].Complexity > r[j].Complexity
+		}
+		return r[i].Asset.String() < r[j].Asset.String()
+	})
+	return r
+}
+
+// Bucket is the number of transactions with complexity within the range and the complexity spent by them.
+type Bucket struct {
+	Range        string `json:"range"`
+	Transactions int    `json:"transactions"`
+	Complexity   int    `json:"complexity"`
+}
+
+// histogramBounds are the inclusive upper bounds of the histogram buckets, except the last unbounded one.
+var histogramBounds = []int{0, 1000, 10000, 52000}
+
+var histogramRanges = []string{"0", "1-1k", "1k-10k", "10k-52k", ">52k"}
+
+func newHistogram() []Bucket {
+	r := make([]Bucket, len(histogramRanges))
+	for i, s := range histogramRanges {
+		r[i].Range = s
+	}
+	return r
+}
+
+// histogram buckets the transactions by complexity, transactions with unknown complexity are skipped.
+func histogram(complexities []Complexity) []Bucket {
+	r := newHistogram()
+	for _, c := range complexities {
+		if c.Unknown {
+			continue
+		}
+		i := sort.SearchInts(histogramBounds, c.SpentComplexity)
+		r[i].Transactions++
+		r[i].Complexity += c.SpentComplexity
+	}
+	return r
+}
+
+func addHistogram(dst, src []Bucket) {
+	for i := range src {
+		dst[i].Transactions += src[i].Transactions
+		dst[i].Complexity += src[i].Complexity
+	}
+}
@@ -0,0 +1,9 @@
+package complexity
+
+// Cache stores the node's responses by key. Implementations must be safe for concurrent use.
+type Cache interface {
+	// Get returns the stored response and true, or false if there is no response with the key.
+	Get(key string) ([]byte, bool)
+	// Put stores the response, failures to store are not reported.
+	Put(key string, data []byte)
+}
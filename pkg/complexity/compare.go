@@ -0,0 +1,37 @@
+package complexity
+
+import "github.com/wavesplatform/gowaves/pkg/crypto"
+
+// Discrepancy is the difference between the results of a transaction of the same block analyzed on two nodes.
+type Discrepancy struct {
+	ID       crypto.Digest `json:"id"`
+	Local    *Complexity   `json:"local"`    // Not set if the transaction is missing in the first block
+	Compared *Complexity   `json:"compared"` // Not set if the transaction is missing in the compared block
+}
+
+// Compare returns the transactions which spent complexity or application status differ between the results of the
+// same block, including the transactions present in only one of them. Discrepancies are in order of transactions
+// of the first block followed by the transactions found only in the compared block.
+func Compare(local, compared BlockComplexity) []Discrepancy {
+	others := make(map[crypto.Digest]*Complexity, len(compared.Transactions))
+	for i := range compared.Transactions {
+		others[compared.Transactions[i].ID] = &compared.Transactions[i]
+	}
+	var r []Discrepancy
+	seen := make(map[crypto.Digest]struct{}, len(local.Transactions))
+	for i := range local.Transactions {
+		c := &local.Transactions[i]
+		seen[c.ID] = struct{}{}
+		o, ok := others[c.ID]
+		if !ok || o.SpentComplexity != c.SpentComplexity || o.ApplicationStatus != c.ApplicationStatus {
+			r = append(r, Discrepancy{ID: c.ID, Local: c, Compared: o})
+		}
+	}
+	for i := range compared.Transactions {
+		o := &compared.Transactions[i]
+		if _, ok := seen[o.ID]; !ok {
+			r = append(r, Discrepancy{ID: o.ID, Compared: o})
+		}
+	}
+	return r
+}
@@ -0,0 +1,678 @@
+// Package complexity calculates the script complexity spent by transactions of Waves blocks.
+package complexity
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/wavesplatform/gowaves/pkg/client"
+	"github.com/wavesplatform/gowaves/pkg/crypto"
+	"github.com/wavesplatform/gowaves/pkg/proto"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// StatusScriptExecutionFailed is the application status of a transaction that was included in the block
+// despite the failure of its script. The complexity spent by such transaction is still charged.
+const StatusScriptExecutionFailed = "script_execution_failed"
+
+// statusSucceeded is the application status of a transaction applied successfully.
+const statusSucceeded = "succeeded"
+
+// ErrNoSpentComplexity is returned in the Strict mode if the node's information about a transaction lacks
+// the spent complexity, as with old versions of the node.
+var ErrNoSpentComplexity = errors.New("node doesn't report spent complexity")
+
+// Complexity is the complexity spent by a single transaction.
+type Complexity struct {
+	ID                 crypto.Digest         `json:"id"`
+	Type               proto.TransactionType `json:"type"`
+	Sender             proto.WavesAddress    `json:"sender"`
+	ApplicationStatus  string                `json:"applicationStatus"`
+	SpentComplexity    int                   `json:"spentComplexity"`
+	Fee                uint64                `json:"fee"`                          // Fee in the smallest units of the fee asset
+	FeeAssetID         string                `json:"feeAssetId,omitempty"`         // Sponsored asset the fee is paid in, empty for WAVES
+	VerifierComplexity int                   `json:"verifierComplexity,omitempty"` // Part of the spent complexity charged for the sender's verifier
+	Invocation         *Invocation           `json:"invocation,omitempty"`
+	SmartAssets        []SmartAsset          `json:"smartAssets,omitempty"` // Scripts of assets moved by the transaction
+	Unknown            bool                  `json:"unknown,omitempty"`     // Spent complexity is not reported by the node
+}
+
+// AssetsComplexity returns the part of the spent complexity charged for scripts of smart assets.
+func (c Complexity) AssetsComplexity() int {
+	total := 0
+	for _, sa := range c.SmartAssets {
+		total += sa.Complexity
+	}
+	return total
+}
+
+// Failed reports whether the transaction's script execution failed.
+func (c Complexity) Failed() bool {
+	return c.ApplicationStatus == StatusScriptExecutionFailed
+}
+
+// BlockComplexity holds complexities of all transactions of the block and their total.
+type BlockComplexity struct {
+	ID                  proto.BlockID      `json:"id"`
+	Height              uint64             `json:"height"`
+	Timestamp           uint64             `json:"timestamp"` // Timestamp of the block in milliseconds
+	Generator           proto.WavesAddress `json:"generator"`
+	Size                uint64             `json:"size"` // Size of the block in bytes
+	BaseTarget          uint64             `json:"baseTarget"`
+	TransactionCount    int                `json:"transactionCount"` // Number of all transactions of the block, including not analyzed
+	Transactions        []Complexity       `json:"transactions"`
+	Complexity          int                `json:"complexity"`
+	FailedTransactions  int                `json:"failedTransactions"`
+	FailedComplexity    int                `json:"failedComplexity"`
+	UnknownTransactions int                `json:"unknownTransactions,omitempty"` // Transactions with unknown complexity, not counted
+	VerifierComplexity  int                `json:"verifierComplexity"`            // Complexity of verifiers of senders' accounts
+	AssetsComplexity    int                `json:"assetsComplexity"`              // Complexity of scripts of smart assets
+	Limit               int                `json:"limit"`
+	Utilization         float64            `json:"utilization"`
+	Fees                uint64             `json:"fees"`             // Fees paid in WAVES, in wavelets
+	FeePerComplexity    float64            `json:"feePerComplexity"` // Fees in wavelets paid per unit of spent complexity
+	Types               []TypeComplexity   `json:"types"`
+	Senders             []SenderComplexity `json:"senders"`
+	Assets              []AssetComplexity  `json:"assets"`
+	Distribution        Distribution       `json:"distribution"` // Distribution of transactions complexities
+	Histogram           []Bucket           `json:"histogram"`
+	Estimated           bool               `json:"estimated,omitempty"` // Complexities are estimated rather than spent
+	Note                string             `json:"note,omitempty"`      // Explanation of complexities, e.g. why they are not requested
+}
+
+// DApps aggregates complexities of the block's InvokeScript transactions by the called dApp, the result is sorted by
+// complexity descending.
+func (b BlockComplexity) DApps() []DAppComplexity {
+	return dAppsComplexities(b.Transactions)
+}
+
+// RangeStats holds the aggregated complexity of a range of blocks.
+type RangeStats struct {
+	Blocks             uint64 `json:"blocks"`
+	Transactions       uint64 `json:"transactions"`
+	Complexity         int    `json:"complexity"`
+	FailedTransactions uint64 `json:"failedTransactions"`
+	FailedComplexity   int    `json:"failedComplexity"`
+	// Transactions which complexity is not reported by the node, not counted in the complexities
+	UnknownTransactions uint64  `json:"unknownTransactions,omitempty"`
+	VerifierComplexity  int     `json:"verifierComplexity"`
+	AssetsComplexity    int     `json:"assetsComplexity"`
+	AverageComplexity   int     `json:"averageComplexity"`
+	MaxComplexity       int     `json:"maxComplexity"`
+	MaxHeight           uint64  `json:"maxHeight"`
+	MaxUtilization      float64 `json:"maxUtilization"`
+	AverageUtilization  float64 `json:"averageUtilization"`
+	AverageSize         uint64  `json:"averageSize"`
+	// Pearson correlation coefficients of complexities of blocks with their sizes and numbers of transactions
+	SizeCorrelation  float64            `json:"sizeCorrelation"`
+	CountCorrelation float64            `json:"transactionCountCorrelation"`
+	Types            []TypeComplexity   `json:"types"`
+	Senders          []SenderComplexity `json:"senders"`
+	Assets           []AssetComplexity  `json:"assets"`
+	// Complexity of InvokeScript transactions by the called dApp, nested calls are not taken into account
+	DApps []DAppComplexity `json:"dApps"`
+	// Leaderboard of generators of the blocks
+	Generators []GeneratorComplexity `json:"generators"`
+	// Distribution of blocks complexities
+	Distribution Distribution `json:"distribution"`
+	// Distribution of numbers of transactions in blocks
+	TransactionsDistribution Distribution `json:"transactionsDistribution"`
+	Histogram                []Bucket     `json:"histogram"`
+}
+
+// Options configures the Analyzer.
+type Options struct {
+	// Concurrency is the number of parallel requests of transactions complexities, one request at a time if not set.
+	Concurrency int
+	// BlockConcurrency is the number of blocks of a range analyzed in parallel, one block at a time if not set.
+	BlockConcurrency int
+	// Scheme is the chain ID used to calculate transaction IDs, taken from the block generator's address if not set.
+	Scheme proto.Scheme
+	// Types restricts the analysis to transactions of the given types, transactions of other types are
+	// neither requested nor included in the results. All transactions are analyzed if not set.
+	Types []proto.TransactionType
+	// Addresses restricts the results to transactions sent by the given addresses or calling dApps with the given
+	// addresses or aliases, including nested calls. Transactions are not filtered by address if not set.
+	Addresses []string
+	// Generator restricts the range of blocks to the blocks forged by the given address, not set by default.
+	Generator string
+	// Verify makes the Analyzer check that every retrieved block is signed by its generator, its ID matches the
+	// signature and its transactions match the transactions root of the header, so tampered responses of the node
+	// are rejected with ErrInvalidBlock.
+	Verify bool
+	// Estimate makes the Analyzer estimate complexities of transactions locally with the Ride estimator using
+	// scripts of accounts and assets, instead of requesting the complexities spent by transactions.
+	Estimate bool
+	// Strict makes the Analyzer fail with ErrNoSpentComplexity if the node doesn't report the complexity spent by
+	// a transaction. Such transactions are counted with zero complexity if neither Strict nor Lenient is set.
+	Strict bool
+	// Lenient makes the Analyzer mark transactions which complexity is not reported by the node as unknown.
+	Lenient bool
+	// ScriptVersions makes the Analyzer annotate the invoked dApps with versions of Ride and estimator of their
+	// scripts, requesting the scripts of dApps. Current scripts of dApps are requested, so versions of the dApps
+	// which scripts were changed after the transaction may differ from the versions in effect on invocation.
+	ScriptVersions bool
+	// CallComplexities makes the Analyzer estimate complexities of the calls of dApps made by transactions, including
+	// nested calls, by the complexities of callable functions of current scripts of the dApps reported by the node.
+	CallComplexities bool
+	// Verifiers makes the Analyzer request the scripts of senders to separate the complexity of verifiers of their
+	// accounts from the spent complexity. The complexity of a verifier is the one estimated by the node.
+	Verifiers bool
+	// Assets makes the Analyzer request details of the assets moved by transactions to separate the complexity of
+	// scripts of smart assets from the spent complexity. The complexity of a script is the one estimated by the node.
+	Assets bool
+	// Cache keeps the node's responses with information about transactions, not used if not set.
+	Cache Cache
+	// Progress is called after the complexity of each transaction of the block at the height is received.
+	// It's called concurrently if the concurrency is greater than one.
+	Progress func(height uint64, processed, total int)
+}
+
+// Analyzer retrieves blocks from the Source and requests complexities of their transactions from the node's REST API.
+type Analyzer struct {
+	cl     *client.Client
+	src    Source
+	opts   Options
+	limits limits
+	ledger ledger // State of the blockchain of the offline analysis, scripts are requested from the node if nil
+}
+
+// NewAnalyzer creates the Analyzer. If the source is nil, blocks are retrieved using the REST API of the client.
+func NewAnalyzer(cl *client.Client, src Source, opts Options) *Analyzer {
+	if src == nil {
+		src = NewRESTSource(cl)
+	}
+	if opts.Concurrency < 1 {
+		opts.Concurrency = 1
+	}
+	if opts.BlockConcurrency < 1 {
+		opts.BlockConcurrency = 1
+	}
+	if opts.Verify {
+		src = verifiedSource{Source: src, scheme: opts.Scheme}
+	}
+	return &Analyzer{cl: cl, src: tracedSource{src}, opts: opts}
+}
+
+// Height returns the current blockchain height.
+func (a *Analyzer) Height(ctx context.Context) (uint64, error) {
+	return a.src.Height(ctx)
+}
+
+// SearchHeight returns the lowest height up to the top one of the block with timestamp not before the given time,
+// or the height next to the top if there is no such block. Timestamps of blocks are expected to grow with height.
+func (a *Analyzer) SearchHeight(ctx context.Context, t time.Time, top uint64) (uint64, error) {
+	ts := uint64(t.UnixMilli())
+	lo, hi := uint64(1), top+1
+	for lo < hi {
+		m := lo + (hi-lo)/2
+		b, err := a.src.BlockAt(ctx, m)
+		if err != nil {
+			return 0, errors.Wrapf(err, "height %d", m)
+		}
+		if b.Timestamp < ts {
+			lo = m + 1
+		} else {
+			hi = m
+		}
+	}
+	return lo, nil
+}
+
+// BlockByID calculates the complexity of the block with the given ID.
+func (a *Analyzer) BlockByID(ctx context.Context, id proto.BlockID) (*BlockComplexity, error) {
+	b, err := a.src.Block(ctx, id)
+	if errors.Is(err, ErrBlockNotFound) {
+		return nil, a.notFound(ctx, id)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get block")
+	}
+	return a.Analyze(ctx, b)
+}
+
+// BlockAt calculates the complexity of the block at the given height.
+func (a *Analyzer) BlockAt(ctx context.Context, height uint64) (*BlockComplexity, error) {
+	b, err := a.src.BlockAt(ctx, height)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get block")
+	}
+	return a.Analyze(ctx, b)
+}
+
+// TransactionHeight returns the height of the block containing the transaction with the given ID.
+func (a *Analyzer) TransactionHeight(ctx context.Context, id crypto.Digest) (uint64, error) {
+	if l, ok := a.ledger.(*stateLedger); ok {
+		return l.st.TransactionHeightByID(id.Bytes())
+	}
+	data, err := a.transactionInfo(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+	var ti struct {
+		Height uint64 `json:"height"`
+	}
+	if err := json.Unmarshal(data, &ti); err != nil {
+		return 0, errors.Wrapf(err, "invalid information about transaction '%s'", id.String())
+	}
+	if ti.Height == 0 {
+		return 0, errors.Errorf("no height in information about transaction '%s'", id.String())
+	}
+	return ti.Height, nil
+}
+
+// LastBlock calculates the complexity of the last block of the blockchain.
+func (a *Analyzer) LastBlock(ctx context.Context) (*BlockComplexity, error) {
+	b, err := a.src.LastBlock(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get block")
+	}
+	return a.Analyze(ctx, b)
+}
+
+// Range calculates complexities of blocks at heights from `from` to `to` inclusive, skipping blocks of other
+// generators if the generator is set in the options. The function `fn`, if not nil, is called with every block
+// as soon as it is processed. Aggregated complexity of the range is returned.
+func (a *Analyzer) Range(ctx context.Context, from, to uint64, fn func(BlockComplexity) error) (*RangeStats, error) {
+	acc := NewAccumulator()
+	if err := a.Accumulate(ctx, acc, from, to, fn); err != nil {
+		return nil, err
+	}
+	st := acc.Stats()
+	return &st, nil
+}
+
+// Accumulate works like Range but adds the complexities of blocks to the accumulator, which keeps the aggregates
+// of the blocks processed before an error, so the range can be continued from the height next to the last processed.
+// Blocks are analyzed in parallel according to the block concurrency option, but are passed to the function
+// and to the accumulator in the order of heights.
+func (a *Analyzer) Accumulate(ctx context.Context, acc *Accumulator, from, to uint64, fn func(BlockComplexity) error) error {
+	if from == 0 || from > to {
+		return errors.Errorf("invalid range [%d, %d]", from, to)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	// The queue holds results of blocks in the order of heights, its capacity bounds the number of blocks in progress
+	queue := make(chan chan rangeBlock, a.opts.BlockConcurrency-1)
+	go func() {
+		defer close(queue)
+		for h := from; h <= to; h++ {
+			c := make(chan rangeBlock, 1)
+			select {
+			case queue <- c:
+			case <-ctx.Done():
+				return
+			}
+			go func(h uint64) {
+				c <- a.rangeBlock(ctx, h)
+			}(h)
+		}
+	}()
+	for c := range queue {
+		var rb rangeBlock
+		select {
+		case rb = <-c:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if rb.err != nil {
+			return rb.err
+		}
+		if rb.bc != nil {
+			if fn != nil {
+				if err := fn(*rb.bc); err != nil {
+					return err
+				}
+			}
+			acc.Add(*rb.bc)
+		}
+		acc.Last = rb.height
+	}
+	return ctx.Err()
+}
+
+// rangeBlock is the result of analysis of a block of a range, the complexity is nil if the block is skipped.
+type rangeBlock struct {
+	height uint64
+	bc     *BlockComplexity
+	err    error
+}
+
+func (a *Analyzer) rangeBlock(ctx context.Context, h uint64) rangeBlock {
+	b, err := a.src.BlockAt(ctx, h)
+	if err != nil {
+		return rangeBlock{height: h, err: errors.Wrapf(err, "height %d: failed to get block", h)}
+	}
+	if a.opts.Generator != "" && b.Generator.String() != a.opts.Generator {
+		return rangeBlock{height: h}
+	}
+	bc, err := a.Analyze(ctx, b)
+	if err != nil {
+		return rangeBlock{height: h, err: errors.Wrapf(err, "height %d", h)}
+	}
+	return rangeBlock{height: h, bc: bc}
+}
+
+// Analyze requests complexities of all transactions of the block, or only of the types set in the options.
+func (a *Analyzer) Analyze(ctx context.Context, b *client.Block) (bc *BlockComplexity, err error) {
+	ctx, span := tracer.Start(ctx, "Analyze", trace.WithAttributes(blockAttributes(b)...))
+	defer func() { endSpan(span, err) }()
+	if err := a.limits.load(ctx, a); err != nil {
+		return nil, errors.Wrap(err, "failed to get features activation status")
+	}
+	scheme := a.opts.Scheme
+	if scheme == 0 {
+		scheme = b.Generator.Bytes()[1]
+	}
+	if a.limits.scriptless(b.Height) {
+		complexities, err := a.scriptlessComplexities(b, scheme)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid transactions of block '%s'", b.ID.String())
+		}
+		bc := a.blockComplexity(b, complexities)
+		bc.Note = fmt.Sprintf("Block precedes activation of smart accounts at height %d, transactions run no scripts and spend no complexity", a.limits.smartAccounts)
+		return bc, nil
+	}
+	complexities, err := a.transactionsComplexities(ctx, b, scheme)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get transactions complexities of block '%s'", b.ID.String())
+	}
+	e := a.newEstimator(scheme, 0)
+	if a.opts.Verifiers && !a.opts.Estimate {
+		if err := e.verifiers(ctx, complexities); err != nil {
+			return nil, errors.Wrapf(err, "failed to get verifiers complexities of block '%s'", b.ID.String())
+		}
+	}
+	if a.opts.Assets && !a.opts.Estimate {
+		if err := e.smartAssets(ctx, complexities, b.Transactions); err != nil {
+			return nil, errors.Wrapf(err, "failed to get smart assets complexities of block '%s'", b.ID.String())
+		}
+	}
+	if a.opts.CallComplexities && !a.opts.Estimate {
+		if err := e.callComplexities(ctx, complexities); err != nil {
+			return nil, errors.Wrapf(err, "failed to estimate calls complexities of block '%s'", b.ID.String())
+		}
+	}
+	if a.opts.ScriptVersions {
+		if err := e.annotate(ctx, complexities, a.limits.estimator(b.Height)); err != nil {
+			return nil, errors.Wrapf(err, "failed to get scripts versions of block '%s'", b.ID.String())
+		}
+	}
+	return a.blockComplexity(b, complexities), nil
+}
+
+// scriptlessComplexities returns zero complexities of the included transactions of the block created before any
+// scripts, without requests to the node.
+func (a *Analyzer) scriptlessComplexities(b *client.Block, scheme proto.Scheme) ([]Complexity, error) {
+	r := make([]Complexity, 0, len(b.Transactions))
+	for _, tx := range b.Transactions {
+		t := tx.GetTypeInfo().Type
+		// Genesis and payment transactions are identified by their signatures rather than digests
+		if !a.included(t) || t == proto.GenesisTransaction || t == proto.PaymentTransaction {
+			continue
+		}
+		id, err := transactionID(tx, scheme)
+		if err != nil {
+			return nil, err
+		}
+		sender, err := transactionSender(tx, scheme)
+		if err != nil {
+			return nil, err
+		}
+		c := Complexity{ID: id, Type: t, Sender: sender, ApplicationStatus: statusSucceeded, Fee: tx.GetFee(), FeeAssetID: feeAsset(tx)}
+		if len(a.opts.Addresses) == 0 || a.matches(c) {
+			r = append(r, c)
+		}
+	}
+	return r, nil
+}
+
+// blockComplexity aggregates the complexities of transactions of the block.
+func (a *Analyzer) blockComplexity(b *client.Block, complexities []Complexity) *BlockComplexity {
+	total := totalComplexity(complexities)
+	failedTxs, failedTotal := failedComplexity(complexities)
+	limit := a.limits.limit(b.Height)
+	return &BlockComplexity{
+		ID:                  b.ID,
+		Height:              b.Height,
+		Timestamp:           b.Timestamp,
+		Generator:           b.Generator,
+		Size:                b.Blocksize,
+		BaseTarget:          b.NxtConsensus.BaseTarget,
+		TransactionCount:    transactionCount(b),
+		Transactions:        complexities,
+		Complexity:          total,
+		FailedTransactions:  failedTxs,
+		FailedComplexity:    failedTotal,
+		UnknownTransactions: unknownTransactions(complexities),
+		VerifierComplexity:  verifierComplexity(complexities),
+		AssetsComplexity:    assetsComplexity(complexities),
+		Limit:               limit,
+		Utilization:         utilization(total, limit),
+		Fees:                totalFees(complexities),
+		FeePerComplexity:    feePerComplexity(complexities),
+		Types:               typesComplexities(complexities),
+		Senders:             sendersComplexities(complexities),
+		Assets:              assetsComplexities(complexities),
+		Distribution:        newDistribution(spentComplexities(complexities)),
+		Histogram:           histogram(complexities),
+		Estimated:           a.opts.Estimate,
+	}
+}
+
+// transactionCount returns the number of transactions of the block, counting them if the node didn't report it.
+func transactionCount(b *client.Block) int {
+	if b.TransactionCount > 0 {
+		return int(b.TransactionCount)
+	}
+	return len(b.Transactions)
+}
+
+func totalComplexity(complexities []Complexity) int {
+	total := 0
+	for _, c := range complexities {
+		total += c.SpentComplexity
+	}
+	return total
+}
+
+func unknownTransactions(complexities []Complexity) int {
+	n := 0
+	for _, c := range complexities {
+		if c.Unknown {
+			n++
+		}
+	}
+	return n
+}
+
+func verifierComplexity(complexities []Complexity) int {
+	total := 0
+	for _, c := range complexities {
+		total += c.VerifierComplexity
+	}
+	return total
+}
+
+func assetsComplexity(complexities []Complexity) int {
+	total := 0
+	for _, c := range complexities {
+		total += c.AssetsComplexity()
+	}
+	return total
+}
+
+// spentComplexities returns the complexities spent by the transactions, skipping the unknown ones.
+func spentComplexities(complexities []Complexity) []int {
+	r := make([]int, 0, len(complexities))
+	for _, c := range complexities {
+		if !c.Unknown {
+			r = append(r, c.SpentComplexity)
+		}
+	}
+	return r
+}
+
+// failedComplexity returns the number of failed transactions and the total complexity spent by them.
+func failedComplexity(complexities []Complexity) (int, int) {
+	n, total := 0, 0
+	for _, c := range complexities {
+		if c.Failed() {
+			n++
+			total += c.SpentComplexity
+		}
+	}
+	return n, total
+}
+
+// included reports whether the transactions of the type are analyzed.
+func (a *Analyzer) included(t proto.TransactionType) bool {
+	if len(a.opts.Types) == 0 {
+		return true
+	}
+	for _, it := range a.opts.Types {
+		if it == t {
+			return true
+		}
+	}
+	return false
+}
+
+// transactionsComplexities requests complexities of the analyzed transactions of the block using parallel requests.
+// The order of the result follows the order of transactions in the block.
+func (a *Analyzer) transactionsComplexities(ctx context.Context, block *client.Block, scheme byte) ([]Complexity, error) {
+	if a.opts.Estimate {
+		return a.estimatedComplexities(ctx, block, scheme)
+	}
+	ids := make([]crypto.Digest, 0, len(block.Transactions))
+	for _, tx := range block.Transactions {
+		if !a.included(tx.GetTypeInfo().Type) {
+			continue
+		}
+		id, err := transactionID(tx, scheme)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var (
+		wg        sync.WaitGroup
+		once      sync.Once
+		firstErr  error
+		processed int32
+	)
+	r := make([]Complexity, len(ids))
+	jobs := make(chan int)
+	for w := 0; w < a.opts.Concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				c, err := a.complexity(ctx, ids[i])
+				if err != nil {
+					once.Do(func() {
+						firstErr = err
+						cancel()
+					})
+					continue
+				}
+				r[i] = *c
+				if a.opts.Progress != nil {
+					a.opts.Progress(block.Height, int(atomic.AddInt32(&processed, 1)), len(ids))
+				}
+			}
+		}()
+	}
+loop:
+	for i := range ids {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break loop
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if len(a.opts.Addresses) == 0 {
+		return r, nil
+	}
+	filtered := r[:0]
+	for _, c := range r {
+		if a.matches(c) {
+			filtered = append(filtered, c)
+		}
+	}
+	return filtered, nil
+}
+
+// matches reports whether the transaction was sent by one of the addresses or called one of them.
+func (a *Analyzer) matches(c Complexity) bool {
+	for _, addr := range a.opts.Addresses {
+		if c.Sender.String() == addr || (c.Invocation != nil && c.Invocation.calls(addr)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *Analyzer) complexity(ctx context.Context, id crypto.Digest) (c *Complexity, err error) {
+	ctx, span := tracer.Start(ctx, "Transaction", trace.WithAttributes(attribute.String("transaction.id", id.String())))
+	defer func() { endSpan(span, err) }()
+	data, err := a.transactionInfo(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	ti := new(transactionInfo)
+	if err := json.Unmarshal(data, ti); err != nil {
+		return nil, errors.Wrapf(err, "invalid information about transaction '%s'", id.String())
+	}
+	res := ti.Complexity
+	switch {
+	case ti.Spent != nil:
+		res.SpentComplexity = *ti.Spent
+	case a.opts.Strict:
+		return nil, errors.Wrapf(ErrNoSpentComplexity, "transaction '%s'", id.String())
+	case a.opts.Lenient:
+		res.Unknown = true
+	}
+	res.Invocation = ti.invocation()
+	return &res, nil
+}
+
+// transactionInfo returns the node's response with information about the transaction, taking it from the cache
+// if possible. Complexity of a transaction never changes, so the cached response never expires.
+func (a *Analyzer) transactionInfo(ctx context.Context, id crypto.Digest) ([]byte, error) {
+	key := id.String()
+	if a.opts.Cache != nil {
+		if data, ok := a.opts.Cache.Get(key); ok {
+			return data, nil
+		}
+	}
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/transactions/info/%s", a.cl.GetOptions().BaseUrl, key), nil)
+	if err != nil {
+		return nil, err
+	}
+	var data json.RawMessage
+	if _, err := a.cl.Do(ctx, req, &data); err != nil {
+		return nil, err
+	}
+	if a.opts.Cache != nil {
+		a.opts.Cache.Put(key, data)
+	}
+	return data, nil
+}
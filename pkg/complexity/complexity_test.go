@@ -0,0 +1,64 @@
+package complexity
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/wavesplatform/gowaves/pkg/client"
+	"github.com/wavesplatform/gowaves/pkg/crypto"
+)
+
+func TestComplexitySpent(t *testing.T) {
+	const (
+		spent   = `{"type":16,"applicationStatus":"succeeded","spentComplexity":1234,"dApp":"3PExampleDApp","call":{"function":"swap"}}`
+		missing = `{"type":16,"applicationStatus":"succeeded","dApp":"3PExampleDApp","call":{"function":"swap"}}`
+		zero    = `{"type":4,"applicationStatus":"succeeded","spentComplexity":0}`
+	)
+	for _, test := range []struct {
+		name    string
+		info    string
+		opts    Options
+		spent   int
+		unknown bool
+		err     error
+	}{
+		{name: "spent", info: spent, spent: 1234},
+		{name: "spent strict", info: spent, opts: Options{Strict: true}, spent: 1234},
+		{name: "spent lenient", info: spent, opts: Options{Lenient: true}, spent: 1234},
+		{name: "zero lenient", info: zero, opts: Options{Lenient: true}},
+		{name: "missing", info: missing},
+		{name: "missing strict", info: missing, opts: Options{Strict: true}, err: ErrNoSpentComplexity},
+		{name: "missing lenient", info: missing, opts: Options{Lenient: true}, unknown: true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(test.info))
+			}))
+			defer srv.Close()
+			cl, err := client.NewClient(client.Options{BaseUrl: srv.URL, Client: srv.Client()})
+			if err != nil {
+				t.Fatal(err)
+			}
+			c, err := NewAnalyzer(cl, nil, test.opts).complexity(context.Background(), crypto.Digest{})
+			if test.err != nil {
+				if !errors.Is(err, test.err) {
+					t.Fatalf("expected error %v, got %v", test.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.SpentComplexity != test.spent || c.Unknown != test.unknown {
+				t.Errorf("expected complexity %d and unknown %t, got %d and %t", test.spent, test.unknown, c.SpentComplexity, c.Unknown)
+			}
+			if c.Invocation != nil && c.Invocation.Function != "swap" {
+				t.Errorf("expected invocation of 'swap', got '%s'", c.Invocation.Function)
+			}
+		})
+	}
+}
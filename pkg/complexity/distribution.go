@@ -0,0 +1,69 @@
+package complexity
+
+import (
+	"math"
+	"sort"
+)
+
+// Distribution describes the distribution of a set of values.
+type Distribution struct {
+	Mean   float64 `json:"mean"`
+	Median int     `json:"median"`
+	P90    int     `json:"p90"`
+	P95    int     `json:"p95"`
+	Max    int     `json:"max"`
+}
+
+// newDistribution calculates the distribution of the values, percentiles are taken by the nearest-rank method.
+func newDistribution(values []int) Distribution {
+	if len(values) == 0 {
+		return Distribution{}
+	}
+	sorted := make([]int, len(values))
+	copy(sorted, values)
+	sort.Ints(sorted)
+	sum := 0
+	for _, v := range sorted {
+		sum += v
+	}
+	return Distribution{
+		Mean:   float64(sum) / float64(len(sorted)),
+		Median: percentile(sorted, 50),
+		P90:    percentile(sorted, 90),
+		P95:    percentile(sorted, 95),
+		Max:    sorted[len(sorted)-1],
+	}
+}
+
+// percentile returns the p-th percentile of the sorted non-empty values.
+func percentile(sorted []int, p float64) int {
+	i := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+	if i < 0 {
+		i = 0
+	}
+	return sorted[i]
+}
+
+// correlation returns the Pearson correlation coefficient of the paired values, zero is returned if there are less
+// than two pairs or any of the values don't vary.
+func correlation(x, y []int) float64 {
+	n := len(x)
+	if n < 2 || len(y) != n {
+		return 0
+	}
+	var sx, sy, sxx, syy, sxy float64
+	for i := range x {
+		xi, yi := float64(x[i]), float64(y[i])
+		sx += xi
+		sy += yi
+		sxx += xi * xi
+		syy += yi * yi
+		sxy += xi * yi
+	}
+	fn := float64(n)
+	d := math.Sqrt(fn*sxx-sx*sx) * math.Sqrt(fn*syy-sy*sy)
+	if d == 0 {
+		return 0
+	}
+	return (fn*sxy - sx*sy) / d
+}
@@ -0,0 +1,408 @@
+package complexity
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/wavesplatform/gowaves/pkg/client"
+	"github.com/wavesplatform/gowaves/pkg/crypto"
+	"github.com/wavesplatform/gowaves/pkg/proto"
+	"github.com/wavesplatform/gowaves/pkg/ride"
+	"github.com/wavesplatform/gowaves/pkg/ride/ast"
+	"github.com/wavesplatform/gowaves/pkg/ride/serialization"
+)
+
+// scriptInfo is the part of the node's response with information about the account script.
+type scriptInfo struct {
+	Script               string         `json:"script"`
+	Complexity           int            `json:"complexity"`
+	VerifierComplexity   int            `json:"verifierComplexity"`
+	CallableComplexities map[string]int `json:"callableComplexities"`
+
+	rideVersion int       // Version of Ride the script is written in, zero if there is no script
+	tree        *ast.Tree // Parsed script, nil if there is no script
+}
+
+// callable returns the complexity of the callable function, or the complexity of the whole script if the function
+// is unknown.
+func (si *scriptInfo) callable(function string) int {
+	if fc, ok := si.CallableComplexities[function]; ok {
+		return fc
+	}
+	return si.Complexity
+}
+
+// assetInfo is the part of the node's response with details of the asset.
+type assetInfo struct {
+	ScriptDetails *struct {
+		Script           string `json:"script"`
+		ScriptComplexity int    `json:"scriptComplexity"`
+	} `json:"scriptDetails"`
+}
+
+// estimator estimates complexities of transactions without their spent complexities as the sum of complexities of
+// the scripts they trigger: the verifier of the sender's account, the called function of the dApp and the scripts
+// of the smart assets they move. Complexities of scripts are reported by the node, or estimated locally if the
+// version of Ride estimator is set. The estimator keeps the requested scripts and resolved aliases, it is not safe
+// for concurrent use.
+type estimator struct {
+	a       *Analyzer
+	scheme  proto.Scheme
+	version int                    // Version of Ride estimator, complexities reported by the node are used if zero
+	scripts map[string]*scriptInfo // By account address
+	assets  map[crypto.Digest]int  // Complexities of asset scripts
+	aliases map[string]string      // Addresses by alias
+}
+
+func (a *Analyzer) newEstimator(scheme proto.Scheme, version int) *estimator {
+	return &estimator{
+		a:       a,
+		scheme:  scheme,
+		version: version,
+		scripts: make(map[string]*scriptInfo),
+		assets:  make(map[crypto.Digest]int),
+		aliases: make(map[string]string),
+	}
+}
+
+// estimatedComplexities estimates complexities of the analyzed transactions of the block locally with the Ride
+// estimator in effect at the block's height. The scripts are requested as they are now, so the estimation of
+// transactions made before a script was changed reflects the current script.
+func (a *Analyzer) estimatedComplexities(ctx context.Context, block *client.Block, scheme byte) ([]Complexity, error) {
+	e := a.newEstimator(scheme, a.limits.estimator(block.Height))
+	r := make([]Complexity, 0, len(block.Transactions))
+	for i, tx := range block.Transactions {
+		t := tx.GetTypeInfo().Type
+		// Genesis and payment transactions are identified by their signatures rather than digests, they run no scripts
+		if !a.included(t) || t == proto.GenesisTransaction || t == proto.PaymentTransaction {
+			continue
+		}
+		c, err := e.estimate(ctx, tx)
+		if err != nil {
+			return nil, err
+		}
+		if a.opts.Progress != nil {
+			a.opts.Progress(block.Height, i+1, len(block.Transactions))
+		}
+		if len(a.opts.Addresses) == 0 || a.matches(*c) {
+			r = append(r, *c)
+		}
+	}
+	return r, nil
+}
+
+func (e *estimator) estimate(ctx context.Context, tx proto.Transaction) (*Complexity, error) {
+	id, err := transactionID(tx, e.scheme)
+	if err != nil {
+		return nil, err
+	}
+	sender, err := transactionSender(tx, e.scheme)
+	if err != nil {
+		return nil, err
+	}
+	c := &Complexity{ID: id, Type: tx.GetTypeInfo().Type, Sender: sender, Fee: tx.GetFee(), FeeAssetID: feeAsset(tx)}
+	if l := e.a.ledger; l != nil {
+		if c.ApplicationStatus, err = l.status(id); err != nil {
+			return nil, errors.Wrapf(err, "failed to get status of transaction '%s'", id.String())
+		}
+	}
+	si, err := e.script(ctx, sender.String())
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get script of account '%s'", sender.String())
+	}
+	c.SpentComplexity, c.VerifierComplexity = si.VerifierComplexity, si.VerifierComplexity
+	switch t := tx.(type) {
+	case *proto.InvokeScriptWithProofs:
+		dApp, err := e.address(ctx, t.ScriptRecipient)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to resolve dApp of transaction '%s'", id.String())
+		}
+		c.Invocation = &Invocation{DApp: dApp, Function: defaultFunction}
+		if !t.FunctionCall.Default {
+			c.Invocation.Function = t.FunctionCall.Name
+		}
+	case *proto.EthereumTransaction:
+		if c.Invocation, err = e.ethereumInvocation(ctx, t); err != nil {
+			return nil, errors.Wrapf(err, "failed to decode invocation of transaction '%s'", id.String())
+		}
+	}
+	if c.Invocation != nil {
+		ds, err := e.script(ctx, c.Invocation.DApp)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get script of dApp '%s'", c.Invocation.DApp)
+		}
+		c.Invocation.Complexity = ds.callable(c.Invocation.Function)
+		c.SpentComplexity += c.Invocation.Complexity
+	}
+	if err := e.assetScripts(ctx, c, tx); err != nil {
+		return nil, err
+	}
+	c.SpentComplexity += c.AssetsComplexity()
+	return c, nil
+}
+
+func (e *estimator) address(ctx context.Context, r proto.Recipient) (string, error) {
+	if r.Address != nil {
+		return r.Address.String(), nil
+	}
+	name := r.Alias.Alias
+	if addr, ok := e.aliases[name]; ok {
+		return addr, nil
+	}
+	if l := e.a.ledger; l != nil {
+		addr, err := l.alias(name)
+		if err != nil {
+			return "", err
+		}
+		e.aliases[name] = addr
+		return addr, nil
+	}
+	addr, _, err := e.a.cl.Alias.Get(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	e.aliases[name] = addr.String()
+	return addr.String(), nil
+}
+
+// script returns complexities of the account's script, zero complexities if the account has no script.
+func (e *estimator) script(ctx context.Context, addr string) (*scriptInfo, error) {
+	if si, ok := e.scripts[addr]; ok {
+		return si, nil
+	}
+	si := new(scriptInfo)
+	if l := e.a.ledger; l != nil {
+		script, err := l.accountScript(addr)
+		if err != nil {
+			return nil, err
+		}
+		si.Script = script
+	} else {
+		req, err := http.NewRequest("GET", fmt.Sprintf("%s/addresses/scriptInfo/%s", e.a.cl.GetOptions().BaseUrl, addr), nil)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := e.a.cl.Do(ctx, req, si); err != nil {
+			return nil, err
+		}
+	}
+	if si.Script != "" {
+		tree, err := parseScript(si.Script)
+		if err != nil {
+			return nil, err
+		}
+		si.rideVersion, si.tree = int(tree.LibVersion), tree
+		if e.version != 0 {
+			est, err := ride.EstimateTree(tree, e.version)
+			if err != nil {
+				return nil, err
+			}
+			si.Complexity, si.CallableComplexities = est.Estimation, est.Functions
+			si.VerifierComplexity = est.Verifier
+			if !tree.IsDApp() {
+				si.VerifierComplexity = est.Estimation
+			}
+		}
+	}
+	e.scripts[addr] = si
+	return si, nil
+}
+
+// asset returns the complexity of the asset's script, zero if the asset is not smart.
+func (e *estimator) asset(ctx context.Context, id crypto.Digest) (int, error) {
+	if c, ok := e.assets[id]; ok {
+		return c, nil
+	}
+	c, script := 0, ""
+	if l := e.a.ledger; l != nil {
+		var err error
+		if script, err = l.assetScript(id); err != nil {
+			return 0, err
+		}
+	} else {
+		req, err := http.NewRequest("GET", fmt.Sprintf("%s/assets/details/%s", e.a.cl.GetOptions().BaseUrl, id.String()), nil)
+		if err != nil {
+			return 0, err
+		}
+		ai := new(assetInfo)
+		if _, err := e.a.cl.Do(ctx, req, ai); err != nil {
+			return 0, err
+		}
+		if sd := ai.ScriptDetails; sd != nil {
+			c, script = sd.ScriptComplexity, sd.Script
+		}
+	}
+	if e.version != 0 && script != "" {
+		tree, err := parseScript(script)
+		if err != nil {
+			return 0, err
+		}
+		est, err := ride.EstimateTree(tree, e.version)
+		if err != nil {
+			return 0, err
+		}
+		c = est.Estimation
+	}
+	e.assets[id] = c
+	return c, nil
+}
+
+// parseScript parses the script given in base64 encoding as the node reports it.
+func parseScript(script string) (*ast.Tree, error) {
+	b, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(script, "base64:"))
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid script")
+	}
+	tree, err := serialization.Parse(b)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid script")
+	}
+	return tree, nil
+}
+
+// verifiers sets complexities of verifiers of the senders' accounts, limited by the complexities spent by the
+// transactions.
+func (e *estimator) verifiers(ctx context.Context, complexities []Complexity) error {
+	for i := range complexities {
+		c := &complexities[i]
+		si, err := e.script(ctx, c.Sender.String())
+		if err != nil {
+			return errors.Wrapf(err, "failed to get script of account '%s'", c.Sender.String())
+		}
+		c.VerifierComplexity = si.VerifierComplexity
+		if c.VerifierComplexity > c.SpentComplexity {
+			c.VerifierComplexity = c.SpentComplexity
+		}
+	}
+	return nil
+}
+
+// smartAssets sets complexities of scripts of smart assets moved by the transactions.
+func (e *estimator) smartAssets(ctx context.Context, complexities []Complexity, txs []proto.Transaction) error {
+	byID := make(map[crypto.Digest]proto.Transaction, len(txs))
+	for _, tx := range txs {
+		id, err := transactionID(tx, e.scheme)
+		if err != nil {
+			return err
+		}
+		byID[id] = tx
+	}
+	for i := range complexities {
+		if tx, ok := byID[complexities[i].ID]; ok {
+			if err := e.assetScripts(ctx, &complexities[i], tx); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (e *estimator) assetScripts(ctx context.Context, c *Complexity, tx proto.Transaction) error {
+	for _, asset := range assets(tx) {
+		ac, err := e.asset(ctx, asset)
+		if err != nil {
+			return errors.Wrapf(err, "failed to get script of asset '%s'", asset.String())
+		}
+		if ac > 0 {
+			c.SmartAssets = append(c.SmartAssets, SmartAsset{Asset: asset, Complexity: ac})
+		}
+	}
+	return nil
+}
+
+// annotate sets versions of Ride of the dApps invoked by the transactions, including nested invocations, and the
+// version of the estimator in effect.
+func (e *estimator) annotate(ctx context.Context, complexities []Complexity, estimator int) error {
+	for _, c := range complexities {
+		if c.Invocation == nil {
+			continue
+		}
+		if err := e.annotateInvocation(ctx, c.Invocation, estimator); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *estimator) annotateInvocation(ctx context.Context, inv *Invocation, estimator int) error {
+	si, err := e.script(ctx, inv.DApp)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get script of dApp '%s'", inv.DApp)
+	}
+	inv.RideVersion, inv.Estimator = si.rideVersion, estimator
+	for i := range inv.Invocations {
+		if err := e.annotateInvocation(ctx, &inv.Invocations[i], estimator); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// callComplexities sets the estimated complexities of the calls of dApps made by the transactions, including nested
+// invocations.
+func (e *estimator) callComplexities(ctx context.Context, complexities []Complexity) error {
+	for _, c := range complexities {
+		if c.Invocation == nil {
+			continue
+		}
+		if err := e.callComplexity(ctx, c.Invocation); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *estimator) callComplexity(ctx context.Context, inv *Invocation) error {
+	si, err := e.script(ctx, inv.DApp)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get script of dApp '%s'", inv.DApp)
+	}
+	inv.Complexity = si.callable(inv.Function)
+	for i := range inv.Invocations {
+		if err := e.callComplexity(ctx, &inv.Invocations[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// assets returns IDs of the assets moved or modified by the transaction, their scripts are run if they are smart.
+func assets(tx proto.Transaction) []crypto.Digest {
+	var r []proto.OptionalAsset
+	switch t := tx.(type) {
+	case *proto.TransferWithProofs:
+		r = append(r, t.AmountAsset)
+	case *proto.TransferWithSig:
+		r = append(r, t.AmountAsset)
+	case *proto.MassTransferWithProofs:
+		r = append(r, t.Asset)
+	case *proto.ReissueWithProofs:
+		return []crypto.Digest{t.AssetID}
+	case *proto.ReissueWithSig:
+		return []crypto.Digest{t.AssetID}
+	case *proto.BurnWithProofs:
+		return []crypto.Digest{t.AssetID}
+	case *proto.BurnWithSig:
+		return []crypto.Digest{t.AssetID}
+	case *proto.SetAssetScriptWithProofs:
+		return []crypto.Digest{t.AssetID}
+	case *proto.InvokeScriptWithProofs:
+		for _, p := range t.Payments {
+			r = append(r, p.Asset)
+		}
+	case proto.Exchange:
+		pair := t.GetOrder1().GetAssetPair()
+		r = append(r, pair.AmountAsset, pair.PriceAsset)
+	}
+	ids := make([]crypto.Digest, 0, len(r))
+	for _, a := range r {
+		if a.Present {
+			ids = append(ids, a.ID)
+		}
+	}
+	return ids
+}
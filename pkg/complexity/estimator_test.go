@@ -0,0 +1,84 @@
+package complexity
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/wavesplatform/gowaves/pkg/client"
+	"github.com/wavesplatform/gowaves/pkg/crypto"
+	"github.com/wavesplatform/gowaves/pkg/proto"
+	"github.com/wavesplatform/gowaves/pkg/proto/ethabi"
+	"github.com/wavesplatform/gowaves/pkg/ride/meta"
+)
+
+// callScript is the dApp of Ride version 4 with the only callable function call() writing an integer entry.
+const callScript = "base64:AAIEAAAAAAAAAAQIAhIAAAAAAAAAAAEAAAABaQEAAAAEY2FsbAAAAAAJAARMAAAAAgkBAAAADEludGVnZXJFbnRyeQAAAAICAAAAA2ludAAAAAAAAAAAAQUAAAADbmlsAAAAAOhqG0I="
+
+func TestEstimateEthereumInvocation(t *testing.T) {
+	senderPK, err := proto.NewEthereumPublicKeyFromHexString("c4f926702fee2456ac5f3d91c9b7aa578ff191d0792fa80b6e65200f2485d9810a89c1bb5830e6618119fb3f2036db47fac027f7883108cbc7b2953539b9cb53")
+	if err != nil {
+		t.Fatal(err)
+	}
+	to, err := proto.NewEthereumAddressFromHexString("0x241Cf7eaf669E0d2FDe4Ba3a534c20B433F4c43d")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dApp, err := to.ToWavesAddress(proto.TestNetScheme)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		si := scriptInfo{}
+		if strings.HasSuffix(r.URL.Path, "/"+dApp.String()) {
+			si = scriptInfo{Script: callScript, Complexity: 7, CallableComplexities: map[string]int{"call": 5}}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(si)
+	}))
+	defer srv.Close()
+	cl, err := client.NewClient(client.Options{BaseUrl: srv.URL, Client: srv.Client()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Callable functions take the payments as the last argument of ABI calls, encoded here as an empty array
+	sig, err := ethabi.NewSignatureFromRideFunctionMeta(meta.Function{Name: "call"}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	selector := sig.Selector()
+	call := append(selector[:], make([]byte, 64)...)
+	call[ethabi.SelectorSize+31] = 32 // Offset of the array
+	for _, test := range []struct {
+		name       string
+		data       []byte
+		function   string
+		complexity int
+	}{
+		{name: "invocation", data: call, function: "call", complexity: 5},
+		{name: "transfer"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			tx := proto.NewEthereumTransaction(&proto.EthereumLegacyTx{
+				To: &to, Value: big.NewInt(0), GasPrice: big.NewInt(int64(proto.EthereumGasPrice)), Gas: 500_000, Data: test.data,
+			}, nil, &crypto.Digest{}, &senderPK, 0)
+			c, err := NewAnalyzer(cl, nil, Options{}).newEstimator(proto.TestNetScheme, 0).estimate(context.Background(), &tx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.SpentComplexity != test.complexity {
+				t.Errorf("expected complexity %d, got %d", test.complexity, c.SpentComplexity)
+			}
+			switch {
+			case test.function == "" && c.Invocation != nil:
+				t.Errorf("unexpected invocation of '%s'", c.Invocation.Function)
+			case test.function != "" && (c.Invocation == nil || c.Invocation.DApp != dApp.String() || c.Invocation.Function != test.function):
+				t.Errorf("expected invocation of %s.%s, got %+v", dApp.String(), test.function, c.Invocation)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package complexity
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/wavesplatform/gowaves/pkg/crypto"
+	"github.com/wavesplatform/gowaves/pkg/proto"
+	"github.com/wavesplatform/gowaves/pkg/proto/ethabi"
+)
+
+// transactionID returns the ID of the transaction as the node reports it. The ID of an Ethereum transaction is
+// the Keccak-256 hash of its signed bytes rather than the hash of the Waves transaction body, so it is taken as is.
+func transactionID(tx proto.Transaction, scheme proto.Scheme) (crypto.Digest, error) {
+	if et, ok := tx.(*proto.EthereumTransaction); ok {
+		if et.ID == nil {
+			if err := et.GenerateID(scheme); err != nil {
+				return crypto.Digest{}, err
+			}
+		}
+		return crypto.Digest(*et.ID), nil
+	}
+	d, err := tx.GetID(scheme)
+	if err != nil {
+		return crypto.Digest{}, err
+	}
+	return crypto.NewDigestFromBytes(d)
+}
+
+// transactionSender returns the Waves address of the transaction sender, an Ethereum transaction is sent by
+// the Waves address derived from the Ethereum address of the signer.
+func transactionSender(tx proto.Transaction, scheme proto.Scheme) (proto.WavesAddress, error) {
+	a, err := tx.GetSender(scheme)
+	if err != nil {
+		return proto.WavesAddress{}, err
+	}
+	return a.ToWavesAddress(scheme)
+}
+
+// ethereumInvocation returns the invocation of the dApp made by the Ethereum transaction, nil if the transaction
+// transfers WAVES or an asset. The called function is decoded from the ABI call data by the meta of the dApp's
+// script, as the node does.
+func (e *estimator) ethereumInvocation(ctx context.Context, tx *proto.EthereumTransaction) (*Invocation, error) {
+	data := tx.Data()
+	if len(data) == 0 {
+		return nil, nil
+	}
+	if len(data) < ethabi.SelectorSize {
+		return nil, errors.Errorf("call data is shorter than %d bytes", ethabi.SelectorSize)
+	}
+	selector, err := ethabi.NewSelectorFromBytes(data[:ethabi.SelectorSize])
+	if err != nil {
+		return nil, err
+	}
+	if ethabi.IsERC20TransferSelector(selector) {
+		return nil, nil
+	}
+	to, err := tx.WavesAddressTo(e.scheme)
+	if err != nil {
+		return nil, err
+	}
+	dApp := to.String()
+	si, err := e.script(ctx, dApp)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get script of dApp '%s'", dApp)
+	}
+	if si.tree == nil || !si.tree.IsDApp() {
+		return nil, errors.Errorf("account '%s' is not a dApp", dApp)
+	}
+	methods, err := ethabi.NewMethodsMapFromRideDAppMeta(si.tree.Meta)
+	if err != nil {
+		return nil, err
+	}
+	call, err := methods.ParseCallDataRide(data)
+	if err != nil {
+		return nil, err
+	}
+	return &Invocation{DApp: dApp, Function: call.Name}, nil
+}
@@ -0,0 +1,77 @@
+package complexity
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+
+	"github.com/pkg/errors"
+	"github.com/wavesplatform/gowaves/pkg/client"
+	g "github.com/wavesplatform/gowaves/pkg/grpc/generated/waves"
+	"github.com/wavesplatform/gowaves/pkg/proto"
+	pb "google.golang.org/protobuf/proto"
+)
+
+// maxExportedBlockSize is the size of a block in the export file above which the file is considered corrupted.
+const maxExportedBlockSize = 2 << 20
+
+// ExportReader reads blocks from the blockchain export file created by the node's export command, where every block
+// is prefixed with its size as 4 bytes big-endian number. Blocks of versions before 5 are in the binary format,
+// later blocks are in protobuf. The file starts with the genesis block, so heights of blocks are their positions.
+type ExportReader struct {
+	r      *bufio.Reader
+	scheme proto.Scheme
+	height uint64
+}
+
+// NewExportReader creates the reader of the export file of the network with the given scheme.
+func NewExportReader(r io.Reader, scheme proto.Scheme) *ExportReader {
+	return &ExportReader{r: bufio.NewReaderSize(r, 1<<20), scheme: scheme}
+}
+
+// Next returns the next block of the file, io.EOF is returned after the last block.
+func (r *ExportReader) Next() (*client.Block, error) {
+	var sb [4]byte
+	if _, err := io.ReadFull(r.r, sb[:]); err != nil {
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+		return nil, errors.Wrapf(err, "failed to read size of block at height %d", r.height+1)
+	}
+	size := binary.BigEndian.Uint32(sb[:])
+	if size == 0 || size > maxExportedBlockSize {
+		return nil, errors.Errorf("corrupted export file: invalid size %d of block at height %d", size, r.height+1)
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r.r, data); err != nil {
+		return nil, errors.Wrapf(err, "failed to read block at height %d", r.height+1)
+	}
+	r.height++
+	b, err := r.block(data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid block at height %d", r.height)
+	}
+	return b, nil
+}
+
+// block decodes the block. The first byte of a binary block is its version, which is less than 5, while a protobuf
+// block starts with the tag of its header.
+func (r *ExportReader) block(data []byte) (*client.Block, error) {
+	if proto.BlockVersion(data[0]) < proto.ProtobufBlockVersion {
+		var b proto.Block
+		if err := b.UnmarshalBinary(data, r.scheme); err != nil {
+			return nil, err
+		}
+		return clientBlock(&b, r.scheme, r.height, uint64(len(data)))
+	}
+	var pbb g.Block
+	if err := pb.Unmarshal(data, &pbb); err != nil {
+		return nil, err
+	}
+	var c proto.ProtobufConverter
+	b, err := c.Block(&pbb)
+	if err != nil {
+		return nil, err
+	}
+	return clientBlock(&b, r.scheme, r.height, uint64(len(data)))
+}
@@ -0,0 +1,58 @@
+package complexity
+
+import "github.com/wavesplatform/gowaves/pkg/proto"
+
+// FeePerComplexity returns the fee in wavelets paid by the transaction per unit of its spent complexity, zero is
+// returned if the transaction spent no complexity or its fee was paid in a sponsored asset.
+func (c Complexity) FeePerComplexity() float64 {
+	if c.SpentComplexity == 0 || c.FeeAssetID != "" {
+		return 0
+	}
+	return float64(c.Fee) / float64(c.SpentComplexity)
+}
+
+// totalFees returns the total of fees paid in WAVES by the transactions.
+func totalFees(complexities []Complexity) uint64 {
+	var total uint64
+	for _, c := range complexities {
+		if c.FeeAssetID == "" {
+			total += c.Fee
+		}
+	}
+	return total
+}
+
+// feePerComplexity returns the fees in wavelets paid per unit of complexity by the transactions which spent
+// complexity and paid their fees in WAVES.
+func feePerComplexity(complexities []Complexity) float64 {
+	var fees uint64
+	spent := 0
+	for _, c := range complexities {
+		if c.SpentComplexity > 0 && c.FeeAssetID == "" {
+			fees += c.Fee
+			spent += c.SpentComplexity
+		}
+	}
+	if spent == 0 {
+		return 0
+	}
+	return float64(fees) / float64(spent)
+}
+
+// feeAsset returns the ID of the sponsored asset the fee of the transaction is paid in, or empty string for WAVES.
+// Only transfers and invocations can have their fees paid in sponsored assets.
+func feeAsset(tx proto.Transaction) string {
+	var a proto.OptionalAsset
+	switch t := tx.(type) {
+	case *proto.TransferWithSig:
+		a = t.FeeAsset
+	case *proto.TransferWithProofs:
+		a = t.FeeAsset
+	case *proto.InvokeScriptWithProofs:
+		a = t.FeeAsset
+	}
+	if !a.Present {
+		return ""
+	}
+	return a.ID.String()
+}
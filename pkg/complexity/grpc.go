@@ -0,0 +1,131 @@
+package complexity
+
+import (
+	"context"
+	"time"
+
+	"github.com/mr-tron/base58"
+	"github.com/pkg/errors"
+	"github.com/wavesplatform/gowaves/pkg/client"
+	g "github.com/wavesplatform/gowaves/pkg/grpc/generated/waves/node/grpc"
+	"github.com/wavesplatform/gowaves/pkg/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	pb "google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// GRPCSource retrieves blocks with transactions using the node's gRPC API in a single request per block.
+type GRPCSource struct {
+	conn    *grpc.ClientConn
+	api     g.BlocksApiClient
+	timeout time.Duration
+}
+
+// NewGRPCSource connects to the node's gRPC API at the given address within the connect timeout,
+// the request timeout limits the duration of every request.
+func NewGRPCSource(addr string, connectTimeout, timeout time.Duration) (*GRPCSource, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout)
+	defer cancel()
+	conn, err := grpc.DialContext(ctx, addr, grpc.WithInsecure(), grpc.WithBlock())
+	if err != nil {
+		return nil, err
+	}
+	return &GRPCSource{conn: conn, api: g.NewBlocksApiClient(conn), timeout: timeout}, nil
+}
+
+// Close closes the connection to the node.
+func (s *GRPCSource) Close() {
+	_ = s.conn.Close()
+}
+
+func (s *GRPCSource) Block(ctx context.Context, id proto.BlockID) (*client.Block, error) {
+	return s.get(ctx, &g.BlockRequest{Request: &g.BlockRequest_BlockId{BlockId: id.Bytes()}, IncludeTransactions: true})
+}
+
+func (s *GRPCSource) BlockAt(ctx context.Context, height uint64) (*client.Block, error) {
+	return s.get(ctx, &g.BlockRequest{Request: &g.BlockRequest_Height{Height: int32(height)}, IncludeTransactions: true})
+}
+
+func (s *GRPCSource) LastBlock(ctx context.Context) (*client.Block, error) {
+	h, err := s.Height(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.BlockAt(ctx, h)
+}
+
+func (s *GRPCSource) Height(ctx context.Context) (uint64, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	h, err := s.api.GetCurrentHeight(ctx, &emptypb.Empty{})
+	if err != nil {
+		return 0, err
+	}
+	return uint64(h.Value), nil
+}
+
+func (s *GRPCSource) get(ctx context.Context, req *g.BlockRequest) (*client.Block, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	res, err := s.api.GetBlock(ctx, req)
+	if status.Code(err) == codes.NotFound {
+		return nil, ErrBlockNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return convertBlock(res)
+}
+
+// convertBlock converts the protobuf block into the same structure as returned by REST API.
+func convertBlock(bh *g.BlockWithHeight) (*client.Block, error) {
+	if bh.Block == nil || bh.Block.Header == nil {
+		return nil, errors.New("empty block")
+	}
+	var c proto.ProtobufConverter
+	b, err := c.Block(bh.Block)
+	if err != nil {
+		return nil, err
+	}
+	return clientBlock(&b, byte(bh.Block.Header.ChainId), uint64(bh.Height), uint64(pb.Size(bh.Block)))
+}
+
+// clientBlock converts the block of the network with the given scheme into the same structure as returned by
+// REST API.
+func clientBlock(b *proto.Block, scheme proto.Scheme, height, size uint64) (*client.Block, error) {
+	if err := b.GenerateBlockID(scheme); err != nil {
+		return nil, err
+	}
+	gen, err := proto.NewAddressFromPublicKey(scheme, b.GeneratorPublicKey)
+	if err != nil {
+		return nil, err
+	}
+	features := make([]uint64, len(b.Features))
+	for i, f := range b.Features {
+		features[i] = uint64(f)
+	}
+	return &client.Block{
+		Headers: client.Headers{
+			Version:   uint64(b.Version),
+			Timestamp: b.Timestamp,
+			Reference: b.Parent,
+			NxtConsensus: client.NxtConsensus{
+				BaseTarget:          b.BaseTarget,
+				GenerationSignature: base58.Encode(b.GenSignature),
+			},
+			TransactionsRoot:   base58.Encode(b.TransactionsRoot),
+			Features:           features,
+			DesiredReward:      b.RewardVote,
+			Generator:          gen,
+			GeneratorPublicKey: b.GeneratorPublicKey.String(),
+			Signature:          b.BlockSignature,
+			Blocksize:          size,
+			TransactionCount:   uint64(b.TransactionCount),
+			Height:             height,
+			ID:                 b.ID,
+		},
+		Transactions: client.TransactionsField(b.Transactions),
+	}, nil
+}
@@ -0,0 +1,85 @@
+package complexity
+
+// defaultFunction is the function called by an InvokeScript transaction without call.
+const defaultFunction = "default"
+
+// Invocation is a call of a dApp's callable function made by an InvokeScript transaction, including
+// the calls this function made to other dApps. The node does not report the complexity spent by a nested
+// call separately, so the complexity of every call can only be estimated from the script of its dApp.
+type Invocation struct {
+	DApp        string       `json:"dApp"`
+	Function    string       `json:"function"`
+	Invocations []Invocation `json:"invocations,omitempty"`
+	// Complexity is the complexity of the called function estimated by the script of the dApp, not the spent one,
+	// excluding the nested calls. It's set only if requested in the options or if complexities are estimated.
+	Complexity int `json:"complexity,omitempty"`
+	// RideVersion is the version of Ride the dApp's script is written in, set only if requested in the options.
+	RideVersion int `json:"rideVersion,omitempty"`
+	// Estimator is the version of Ride estimator the node estimates scripts with, set only if requested in the options.
+	Estimator int `json:"estimator,omitempty"`
+}
+
+// calls reports whether the invocation or any of its nested invocations called the dApp.
+func (inv *Invocation) calls(dApp string) bool {
+	if inv.DApp == dApp {
+		return true
+	}
+	for i := range inv.Invocations {
+		if inv.Invocations[i].calls(dApp) {
+			return true
+		}
+	}
+	return false
+}
+
+// transactionInfo is the part of the node's transaction info response required to calculate complexity.
+type transactionInfo struct {
+	Complexity
+	Spent        *int          `json:"spentComplexity"` // Not set if the node doesn't report spent complexity
+	DApp         string        `json:"dApp"`
+	Call         *call         `json:"call"`
+	StateChanges *stateChanges `json:"stateChanges"`
+	Payload      *invoke       `json:"payload"` // Invocation made by an Ethereum transaction
+}
+
+type call struct {
+	Function string `json:"function"`
+}
+
+type stateChanges struct {
+	Invokes []invoke `json:"invokes"`
+}
+
+type invoke struct {
+	DApp         string        `json:"dApp"`
+	Call         call          `json:"call"`
+	StateChanges *stateChanges `json:"stateChanges"`
+}
+
+// invocation builds the call tree of an InvokeScript transaction or an Ethereum transaction invoking a dApp,
+// nil is returned for other transactions.
+func (ti *transactionInfo) invocation() *Invocation {
+	if ti.DApp == "" && ti.Payload != nil && ti.Payload.DApp != "" {
+		return &Invocation{DApp: ti.Payload.DApp, Function: ti.Payload.Call.Function, Invocations: invocations(ti.Payload.StateChanges)}
+	}
+	if ti.DApp == "" {
+		return nil
+	}
+	r := &Invocation{DApp: ti.DApp, Function: defaultFunction}
+	if ti.Call != nil {
+		r.Function = ti.Call.Function
+	}
+	r.Invocations = invocations(ti.StateChanges)
+	return r
+}
+
+func invocations(sc *stateChanges) []Invocation {
+	if sc == nil || len(sc.Invokes) == 0 {
+		return nil
+	}
+	r := make([]Invocation, len(sc.Invokes))
+	for i, inv := range sc.Invokes {
+		r[i] = Invocation{DApp: inv.DApp, Function: inv.Call.Function, Invocations: invocations(inv.StateChanges)}
+	}
+	return r
+}
@@ -0,0 +1,124 @@
+package complexity
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+const (
+	smartAccountsFeature = 4  // Smart accounts, the first scripts
+	blockRewardFeature   = 14 // Block reward, Ride estimator V2
+	blockV5Feature       = 15 // Ride V4, VRF, Protobuf, Failed transactions, Ride estimator V3
+	rideV5Feature        = 16 // Ride V5, dApp-to-dApp invocations
+	rideV6Feature        = 17 // Ride V6, Ethereum transactions, Ride estimator V4
+)
+
+const (
+	// MaxBlockComplexityBeforeRideV5 is the limit of total complexity of scripts in a block after the activation
+	// of feature BlockV5 (15) and before the activation of feature RideV5 (16). Before BlockV5 blocks were limited
+	// by the number of script runs rather than by complexity.
+	MaxBlockComplexityBeforeRideV5 = 1000000
+	// MaxBlockComplexityAfterRideV5 is the limit of total complexity of scripts in a block after the activation
+	// of feature RideV5 (16).
+	MaxBlockComplexityAfterRideV5 = 2500000
+)
+
+type activationStatus struct {
+	Features []struct {
+		ID               int    `json:"id"`
+		ActivationHeight uint64 `json:"activationHeight"`
+	} `json:"features"`
+}
+
+// limits holds activation heights of features affecting the block complexity limit and estimation of scripts.
+// Zero height means that the feature is not activated.
+type limits struct {
+	mu            sync.Mutex
+	loaded        bool
+	smartAccounts uint64
+	blockReward   uint64
+	blockV5       uint64
+	rideV5        uint64
+	rideV6        uint64
+}
+
+// load requests activation heights of features from the node, once succeeded the heights are not requested again.
+func (l *limits) load(ctx context.Context, a *Analyzer) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.loaded {
+		return nil
+	}
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/activation/status", a.cl.GetOptions().BaseUrl), nil)
+	if err != nil {
+		return err
+	}
+	st := new(activationStatus)
+	if _, err := a.cl.Do(ctx, req, st); err != nil {
+		return err
+	}
+	for _, f := range st.Features {
+		l.activate(f.ID, f.ActivationHeight)
+	}
+	l.loaded = true
+	return nil
+}
+
+// activate sets the activation height of the feature, features not affecting the limit or estimation are ignored.
+func (l *limits) activate(feature int, height uint64) {
+	switch feature {
+	case smartAccountsFeature:
+		l.smartAccounts = height
+	case blockRewardFeature:
+		l.blockReward = height
+	case blockV5Feature:
+		l.blockV5 = height
+	case rideV5Feature:
+		l.rideV5 = height
+	case rideV6Feature:
+		l.rideV6 = height
+	}
+}
+
+// limit returns the maximum total complexity of a block at the given height, zero if the block is not limited
+// by complexity.
+func (l *limits) limit(height uint64) int {
+	switch {
+	case l.rideV5 != 0 && height >= l.rideV5:
+		return MaxBlockComplexityAfterRideV5
+	case l.blockV5 != 0 && height >= l.blockV5:
+		return MaxBlockComplexityBeforeRideV5
+	default:
+		return 0
+	}
+}
+
+// scriptless reports whether the block at the given height was created before the activation of smart accounts,
+// so its transactions could not run scripts. Blocks are not considered scriptless if the activation is unknown.
+func (l *limits) scriptless(height uint64) bool {
+	return l.smartAccounts != 0 && height < l.smartAccounts
+}
+
+// estimator returns the version of Ride estimator used by the node to estimate scripts at the given height.
+func (l *limits) estimator(height uint64) int {
+	switch {
+	case l.rideV6 != 0 && height >= l.rideV6:
+		return 4
+	case l.blockV5 != 0 && height >= l.blockV5:
+		return 3
+	case l.blockReward != 0 && height >= l.blockReward:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// utilization returns the complexity as a percentage of the limit, zero if there is no limit.
+func utilization(complexity, limit int) float64 {
+	if limit == 0 {
+		return 0
+	}
+	return float64(complexity) * 100 / float64(limit)
+}
@@ -0,0 +1,64 @@
+package complexity
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/wavesplatform/gowaves/pkg/proto"
+)
+
+// ErrBlockNotFound is returned by a Source if the requested block does not exist on the node's blockchain.
+var ErrBlockNotFound = errors.New("block not found")
+
+// NetworkName returns the human-readable name of the network with the given chain ID.
+func NetworkName(scheme proto.Scheme) string {
+	switch scheme {
+	case proto.MainNetScheme:
+		return "mainnet"
+	case proto.TestNetScheme:
+		return "testnet"
+	case proto.StageNetScheme:
+		return "stagenet"
+	default:
+		return fmt.Sprintf("custom network '%c'", scheme)
+	}
+}
+
+// Scheme returns the chain ID of the node's blockchain taken from the address of the last block generator.
+func (a *Analyzer) Scheme(ctx context.Context) (proto.Scheme, error) {
+	b, err := a.src.LastBlock(ctx)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to get last block")
+	}
+	return b.Generator.Bytes()[1], nil
+}
+
+// CheckNetwork returns an error if the chain ID set in the options differs from the chain ID of the node.
+func (a *Analyzer) CheckNetwork(ctx context.Context) error {
+	if a.opts.Scheme == 0 {
+		return nil
+	}
+	scheme, err := a.Scheme(ctx)
+	if err != nil {
+		return err
+	}
+	if scheme != a.opts.Scheme {
+		return errors.Errorf("node belongs to %s, but %s is requested", NetworkName(scheme), NetworkName(a.opts.Scheme))
+	}
+	return nil
+}
+
+// notFound explains why the block with the given ID was not found on the node.
+func (a *Analyzer) notFound(ctx context.Context, id proto.BlockID) error {
+	scheme, err := a.Scheme(ctx)
+	if err != nil {
+		return errors.Wrapf(ErrBlockNotFound, "block '%s'", id.String())
+	}
+	h, err := a.src.Height(ctx)
+	if err != nil {
+		return errors.Wrapf(ErrBlockNotFound, "block '%s'", id.String())
+	}
+	return errors.Wrapf(ErrBlockNotFound,
+		"no block '%s' on %s up to height %d, it may belong to another network", id.String(), NetworkName(scheme), h)
+}
@@ -0,0 +1,210 @@
+package complexity
+
+import (
+	"context"
+	"encoding/base64"
+
+	"github.com/pkg/errors"
+	"github.com/wavesplatform/gowaves/pkg/client"
+	"github.com/wavesplatform/gowaves/pkg/crypto"
+	"github.com/wavesplatform/gowaves/pkg/proto"
+	"github.com/wavesplatform/gowaves/pkg/settings"
+)
+
+// ledger is the state of the blockchain needed to estimate transactions without requests to the node.
+type ledger interface {
+	// accountScript returns the base64 encoded script of the account, empty if the account has no script.
+	accountScript(addr string) (string, error)
+	// assetScript returns the base64 encoded script of the asset, empty if the asset is not smart.
+	assetScript(id crypto.Digest) (string, error)
+	// alias returns the address the alias belongs to.
+	alias(name string) (string, error)
+	// status returns the application status of the transaction, empty if it's unknown.
+	status(id crypto.Digest) (string, error)
+}
+
+// blocksLedger is the state of the blockchain collected from the applied transactions.
+type blocksLedger struct {
+	scripts map[string]string        // Base64 encoded scripts by account address
+	assets  map[crypto.Digest]string // Base64 encoded scripts of smart assets
+	aliases map[string]string        // Addresses by alias
+}
+
+func (l *blocksLedger) accountScript(addr string) (string, error) {
+	return l.scripts[addr], nil
+}
+
+func (l *blocksLedger) assetScript(id crypto.Digest) (string, error) {
+	return l.assets[id], nil
+}
+
+func (l *blocksLedger) alias(name string) (string, error) {
+	addr, ok := l.aliases[name]
+	if !ok {
+		return "", errors.Errorf("unknown alias '%s'", name)
+	}
+	return addr, nil
+}
+
+func (l *blocksLedger) status(crypto.Digest) (string, error) {
+	return "", nil
+}
+
+// apply updates the state with the scripts set and the aliases created by the transactions.
+func (l *blocksLedger) apply(txs []proto.Transaction, scheme proto.Scheme) error {
+	for _, tx := range txs {
+		switch t := tx.(type) {
+		case *proto.SetScriptWithProofs:
+			addr, err := proto.NewAddressFromPublicKey(scheme, t.SenderPK)
+			if err != nil {
+				return err
+			}
+			if len(t.Script) == 0 {
+				delete(l.scripts, addr.String())
+			} else {
+				l.scripts[addr.String()] = base64.StdEncoding.EncodeToString(t.Script)
+			}
+		case *proto.IssueWithProofs:
+			if len(t.Script) == 0 {
+				continue
+			}
+			id, err := transactionID(t, scheme)
+			if err != nil {
+				return err
+			}
+			l.assets[id] = base64.StdEncoding.EncodeToString(t.Script)
+		case *proto.SetAssetScriptWithProofs:
+			l.assets[t.AssetID] = base64.StdEncoding.EncodeToString(t.Script)
+		case *proto.CreateAliasWithSig:
+			if err := l.createAlias(t.SenderPK, t.Alias, scheme); err != nil {
+				return err
+			}
+		case *proto.CreateAliasWithProofs:
+			if err := l.createAlias(t.SenderPK, t.Alias, scheme); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (l *blocksLedger) createAlias(pk crypto.PublicKey, alias proto.Alias, scheme proto.Scheme) error {
+	addr, err := proto.NewAddressFromPublicKey(scheme, pk)
+	if err != nil {
+		return err
+	}
+	l.aliases[alias.Alias] = addr.String()
+	return nil
+}
+
+// Offline estimates complexities of transactions of blocks without a node, as the Analyzer does with the Estimate
+// option. Blocks must be given in the order of heights starting from the genesis block: scripts of accounts and
+// assets and aliases are taken from the transactions of the previous blocks, and activation heights of features
+// are derived from votes of generators of the blocks following the settings of the network.
+type Offline struct {
+	a        *Analyzer
+	l        *blocksLedger
+	fs       *settings.FunctionalitySettings
+	votes    map[int16]uint64 // Votes for features in the current voting period
+	approved map[int16]bool
+	height   uint64 // Height of the last given block
+}
+
+// NewOffline creates the offline analyzer of blocks of mainnet, testnet, stagenet or a custom network with the
+// scheme set in the options. Custom networks are expected to have the default settings of feature voting.
+func NewOffline(opts Options) (*Offline, error) {
+	var bs *settings.BlockchainSettings
+	switch opts.Scheme {
+	case 0:
+		return nil, errors.New("scheme is not set")
+	case proto.MainNetScheme:
+		bs = settings.MainNetSettings
+	case proto.TestNetScheme:
+		bs = settings.TestNetSettings
+	case proto.StageNetScheme:
+		bs = settings.StageNetSettings
+	default:
+		bs = settings.DefaultCustomSettings
+	}
+	opts.Estimate = true
+	a := NewAnalyzer(nil, nil, opts)
+	a.limits.loaded = true
+	l := &blocksLedger{
+		scripts: make(map[string]string),
+		assets:  make(map[crypto.Digest]string),
+		aliases: make(map[string]string),
+	}
+	a.ledger = l
+	o := &Offline{a: a, l: l, fs: &bs.FunctionalitySettings, votes: make(map[int16]uint64), approved: make(map[int16]bool)}
+	for _, f := range bs.PreactivatedFeatures {
+		o.approved[f] = true
+		a.limits.activate(int(f), 1)
+	}
+	return o, nil
+}
+
+// Scheme returns the chain ID of the analyzed blockchain.
+func (o *Offline) Scheme() proto.Scheme {
+	return o.a.opts.Scheme
+}
+
+// Analyze estimates complexities of the transactions of the next block and applies the block.
+func (o *Offline) Analyze(ctx context.Context, b *client.Block) (*BlockComplexity, error) {
+	if err := o.next(b); err != nil {
+		return nil, err
+	}
+	complexities, err := o.a.estimatedComplexities(ctx, b, o.a.opts.Scheme)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to estimate transactions complexities of block '%s'", b.ID.String())
+	}
+	if err := o.apply(b); err != nil {
+		return nil, err
+	}
+	return o.a.blockComplexity(b, complexities), nil
+}
+
+// Skip applies the next block without estimation of its transactions.
+func (o *Offline) Skip(b *client.Block) error {
+	if err := o.next(b); err != nil {
+		return err
+	}
+	return o.apply(b)
+}
+
+// next returns an error unless the block is the next one.
+func (o *Offline) next(b *client.Block) error {
+	if b.Height != o.height+1 {
+		return errors.Errorf("block at height %d given after height %d", b.Height, o.height)
+	}
+	return nil
+}
+
+func (o *Offline) apply(b *client.Block) error {
+	o.height = b.Height
+	o.vote(b)
+	if err := o.l.apply(b.Transactions, o.a.opts.Scheme); err != nil {
+		return errors.Wrapf(err, "failed to apply block '%s'", b.ID.String())
+	}
+	return nil
+}
+
+// vote counts votes of the block for features. At the end of a voting period the features which received enough
+// votes are approved and activated after another period, as the node does.
+func (o *Offline) vote(b *client.Block) {
+	for _, f := range b.Features {
+		if !o.approved[int16(f)] {
+			o.votes[int16(f)]++
+		}
+	}
+	window := o.fs.ActivationWindowSize(b.Height)
+	if b.Height%window != 0 {
+		return
+	}
+	for f, n := range o.votes {
+		if n >= o.fs.VotesForFeatureElection(b.Height) {
+			o.approved[f] = true
+			o.a.limits.activate(int(f), b.Height+window)
+		}
+	}
+	o.votes = make(map[int16]uint64)
+}
@@ -0,0 +1,80 @@
+package complexity
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// MaxBlockAge is the age of the last block of the node above which the node is considered not synchronized.
+const MaxBlockAge = 10 * time.Minute
+
+// ErrNoTransactionsInfo is returned by the preflight check if the node doesn't provide information about
+// transactions with their spent complexities, so complexities can only be estimated locally.
+var ErrNoTransactionsInfo = errors.New("node doesn't provide information about transactions")
+
+// NodeStatus is the state of the node found by the preflight check.
+type NodeStatus struct {
+	Version string
+	Height  uint64
+	Age     time.Duration // Time passed since the creation of the last block
+}
+
+type nodeVersion struct {
+	Version string `json:"version"`
+}
+
+type nodeStatus struct {
+	BlockchainHeight uint64 `json:"blockchainHeight"`
+	StateHeight      uint64 `json:"stateHeight"`
+}
+
+// Preflight checks that the node is available, synchronized and provides the API required by the analysis,
+// so the analysis fails at once with an explanation rather than on requests of blocks and transactions.
+func (a *Analyzer) Preflight(ctx context.Context) (*NodeStatus, error) {
+	v := new(nodeVersion)
+	if err := a.get(ctx, "/node/version", v); err != nil {
+		return nil, errors.Wrap(err, "node is not available")
+	}
+	st := new(nodeStatus)
+	if err := a.get(ctx, "/node/status", st); err != nil {
+		return nil, errors.Wrap(err, "failed to get status of the node")
+	}
+	if st.StateHeight < st.BlockchainHeight {
+		return nil, errors.Errorf("node %s is not synchronized: blocks are applied up to height %d of %d, wait for the node to apply the blocks or use another node",
+			v.Version, st.StateHeight, st.BlockchainHeight)
+	}
+	b, err := a.src.LastBlock(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get last block")
+	}
+	s := &NodeStatus{Version: v.Version, Height: b.Height, Age: time.Since(time.UnixMilli(int64(b.Timestamp)))}
+	if s.Age > MaxBlockAge {
+		return nil, errors.Errorf("node %s is not synchronized: its last block at height %d was created %s ago, wait for the node to catch up or use another node",
+			v.Version, b.Height, s.Age.Round(time.Second))
+	}
+	if a.opts.Estimate || len(b.Transactions) == 0 {
+		return s, nil
+	}
+	id, err := transactionID(b.Transactions[0], b.Generator.Bytes()[1])
+	if err != nil {
+		return nil, err
+	}
+	if _, err := a.transactionInfo(ctx, id); err != nil {
+		return nil, errors.Wrapf(ErrNoTransactionsInfo, "node %s: %v", v.Version, err)
+	}
+	return s, nil
+}
+
+// get requests the node's REST API at the path and decodes the response to the value.
+func (a *Analyzer) get(ctx context.Context, path string, v any) error {
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s%s", a.cl.GetOptions().BaseUrl, path), nil)
+	if err != nil {
+		return err
+	}
+	_, err = a.cl.Do(ctx, req, v)
+	return err
+}
@@ -0,0 +1,65 @@
+package complexity
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/wavesplatform/gowaves/pkg/client"
+	"github.com/wavesplatform/gowaves/pkg/proto"
+)
+
+// Source retrieves blocks with transactions from the node.
+type Source interface {
+	Block(ctx context.Context, id proto.BlockID) (*client.Block, error)
+	BlockAt(ctx context.Context, height uint64) (*client.Block, error)
+	LastBlock(ctx context.Context) (*client.Block, error)
+	Height(ctx context.Context) (uint64, error)
+}
+
+// RESTSource retrieves blocks using the node's REST API.
+type RESTSource struct {
+	cl *client.Client
+}
+
+// NewRESTSource creates the source retrieving blocks with the given client.
+func NewRESTSource(cl *client.Client) *RESTSource {
+	return &RESTSource{cl: cl}
+}
+
+func (s *RESTSource) Block(ctx context.Context, id proto.BlockID) (*client.Block, error) {
+	block, resp, err := s.cl.Blocks.Signature(ctx, id)
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return nil, ErrBlockNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return block, nil
+}
+
+func (s *RESTSource) BlockAt(ctx context.Context, height uint64) (*client.Block, error) {
+	block, resp, err := s.cl.Blocks.At(ctx, height)
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return nil, ErrBlockNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return block, nil
+}
+
+func (s *RESTSource) LastBlock(ctx context.Context) (*client.Block, error) {
+	block, _, err := s.cl.Blocks.Last(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return block, nil
+}
+
+func (s *RESTSource) Height(ctx context.Context) (uint64, error) {
+	h, _, err := s.cl.Blocks.Height(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return h.Height, nil
+}
@@ -0,0 +1,217 @@
+package complexity
+
+import (
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/pkg/errors"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/wavesplatform/gowaves/pkg/client"
+	"github.com/wavesplatform/gowaves/pkg/crypto"
+	"github.com/wavesplatform/gowaves/pkg/proto"
+	"github.com/wavesplatform/gowaves/pkg/settings"
+	"github.com/wavesplatform/gowaves/pkg/state"
+)
+
+const (
+	stateDBDir      = "key_value" // Directory of the node's database within the state directory
+	stateInfoPrefix = 36          // Key of the record of parameters the state was created with
+)
+
+// stateInfo is the record of parameters the node created its state with, the state is opened with the same ones.
+type stateInfo struct {
+	Version            uint16 `cbor:"0,keyasint,omitempty"`
+	Amend              bool   `cbor:"1,keyasint,omitempty"`
+	HasExtendedAPIData bool   `cbor:"2,keyasint,omitempty"`
+	HasStateHashes     bool   `cbor:"3,keyasint,omitempty"`
+}
+
+// StateSource reads blocks, scripts and aliases directly from the state of a local gowaves node, without requests
+// to the node. The database of the state can be opened by a single process only, so the node must be stopped or
+// a copy of its state must be given. Scripts are taken as they are at the last block of the state.
+type StateSource struct {
+	st     state.State
+	scheme proto.Scheme
+}
+
+// OpenState opens the state in the given directory of the node of mainnet, testnet or stagenet with the scheme.
+// Blocks are never added to the state, but gowaves has no read-only access to it: the state is opened for writing
+// and the node's library may upgrade or compact its database, so only a copy of the state should be given unless
+// that is acceptable. The library logs with the global logger of zap, which the program may replace.
+func OpenState(dir string, scheme proto.Scheme) (*StateSource, error) {
+	var bs settings.BlockchainSettings
+	switch scheme {
+	case proto.MainNetScheme:
+		bs = *settings.MainNetSettings
+	case proto.TestNetScheme:
+		bs = *settings.TestNetSettings
+	case proto.StageNetScheme:
+		bs = *settings.StageNetSettings
+	default:
+		return nil, errors.Errorf("state of %s is not supported, only mainnet, testnet and stagenet are", NetworkName(scheme))
+	}
+	info, err := readStateInfo(dir)
+	if err != nil {
+		return nil, err
+	}
+	params := state.DefaultStateParams()
+	params.StoreExtendedApiData = info.HasExtendedAPIData
+	params.ProvideExtendedApi = info.HasExtendedAPIData
+	params.BuildStateHashes = info.HasStateHashes
+	st, err := state.NewState(dir, false, params, &bs)
+	if err != nil {
+		return nil, err
+	}
+	return &StateSource{st: st, scheme: scheme}, nil
+}
+
+// readStateInfo reads the parameters of the state in the directory. It also ensures that the directory contains
+// a state, otherwise the node's library would create a new one there.
+func readStateInfo(dir string) (*stateInfo, error) {
+	path := filepath.Join(dir, stateDBDir)
+	if _, err := os.Stat(path); err != nil {
+		return nil, errors.Errorf("no state of gowaves node in '%s'", dir)
+	}
+	db, err := leveldb.OpenFile(path, &opt.Options{ReadOnly: true, ErrorIfMissing: true})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open database of the state, it may be used by the running node")
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+	data, err := db.Get([]byte{stateInfoPrefix}, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read parameters of the state")
+	}
+	info := new(stateInfo)
+	if err := cbor.Unmarshal(data, info); err != nil {
+		return nil, errors.Wrap(err, "invalid parameters of the state")
+	}
+	return info, nil
+}
+
+// Close closes the state.
+func (s *StateSource) Close() error {
+	return s.st.Close()
+}
+
+func (s *StateSource) Block(ctx context.Context, id proto.BlockID) (*client.Block, error) {
+	h, err := s.st.BlockIDToHeight(id)
+	if state.IsNotFound(err) {
+		return nil, ErrBlockNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.BlockAt(ctx, h)
+}
+
+func (s *StateSource) BlockAt(_ context.Context, height uint64) (*client.Block, error) {
+	b, err := s.st.BlockByHeight(height)
+	if state.IsNotFound(err) {
+		return nil, ErrBlockNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	data, err := b.Marshal(s.scheme)
+	if err != nil {
+		return nil, err
+	}
+	return clientBlock(b, s.scheme, height, uint64(len(data)))
+}
+
+func (s *StateSource) LastBlock(ctx context.Context) (*client.Block, error) {
+	h, err := s.Height(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.BlockAt(ctx, h)
+}
+
+func (s *StateSource) Height(context.Context) (uint64, error) {
+	return s.st.Height()
+}
+
+// NewStateAnalyzer creates the Analyzer of blocks of the state, which estimates complexities of transactions as
+// with the Estimate option, taking scripts, aliases, statuses of transactions and activation heights of features
+// from the state.
+func NewStateAnalyzer(s *StateSource, opts Options) (*Analyzer, error) {
+	opts.Scheme, opts.Estimate = s.scheme, true
+	a := NewAnalyzer(nil, s, opts)
+	for _, f := range []int16{smartAccountsFeature, blockRewardFeature, blockV5Feature, rideV5Feature, rideV6Feature} {
+		ok, err := s.st.IsActivated(f)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get status of feature %d", f)
+		}
+		if !ok {
+			continue
+		}
+		h, err := s.st.ActivationHeight(f)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get activation height of feature %d", f)
+		}
+		a.limits.activate(int(f), h)
+	}
+	a.limits.loaded = true
+	a.ledger = &stateLedger{st: s.st, scheme: s.scheme}
+	return a, nil
+}
+
+// stateLedger is the state of the blockchain read from the node's state.
+type stateLedger struct {
+	st     state.State
+	scheme proto.Scheme
+}
+
+func (l *stateLedger) accountScript(addr string) (string, error) {
+	a, err := proto.NewAddressFromString(addr)
+	if err != nil {
+		return "", err
+	}
+	script, err := l.st.NewestScriptBytesByAccount(proto.NewRecipientFromAddress(a))
+	if state.IsNotFound(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(script), nil
+}
+
+func (l *stateLedger) assetScript(id crypto.Digest) (string, error) {
+	si, err := l.st.ScriptInfoByAsset(proto.AssetIDFromDigest(id))
+	if state.IsNotFound(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return si.Base64, nil
+}
+
+func (l *stateLedger) alias(name string) (string, error) {
+	addr, err := l.st.AddrByAlias(*proto.NewAlias(l.scheme, name))
+	if state.IsNotFound(err) {
+		return "", errors.Errorf("unknown alias '%s'", name)
+	}
+	if err != nil {
+		return "", err
+	}
+	return addr.String(), nil
+}
+
+func (l *stateLedger) status(id crypto.Digest) (string, error) {
+	_, failed, err := l.st.TransactionByIDWithStatus(id.Bytes())
+	if err != nil {
+		return "", err
+	}
+	if failed {
+		return StatusScriptExecutionFailed, nil
+	}
+	return statusSucceeded, nil
+}
@@ -0,0 +1,68 @@
+package complexity
+
+import (
+	"context"
+
+	"github.com/wavesplatform/gowaves/pkg/client"
+	"github.com/wavesplatform/gowaves/pkg/proto"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer creates spans with the globally registered OpenTelemetry tracer provider, spans are not recorded unless
+// the provider is set by the application.
+var tracer = otel.Tracer("github.com/alexeykiselev/waves-block-complexity/pkg/complexity")
+
+// endSpan records the error, if any, and ends the span.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// tracedSource creates a span for every block retrieved from the underlying source.
+type tracedSource struct {
+	Source
+}
+
+func (s tracedSource) Block(ctx context.Context, id proto.BlockID) (b *client.Block, err error) {
+	ctx, span := tracer.Start(ctx, "Block", trace.WithAttributes(attribute.String("block.id", id.String())))
+	defer func() { endSpan(span, err) }()
+	b, err = s.Source.Block(ctx, id)
+	if err == nil {
+		span.SetAttributes(blockAttributes(b)...)
+	}
+	return b, err
+}
+
+func (s tracedSource) BlockAt(ctx context.Context, height uint64) (b *client.Block, err error) {
+	ctx, span := tracer.Start(ctx, "BlockAt", trace.WithAttributes(attribute.Int64("block.height", int64(height))))
+	defer func() { endSpan(span, err) }()
+	b, err = s.Source.BlockAt(ctx, height)
+	if err == nil {
+		span.SetAttributes(blockAttributes(b)...)
+	}
+	return b, err
+}
+
+func (s tracedSource) LastBlock(ctx context.Context) (b *client.Block, err error) {
+	ctx, span := tracer.Start(ctx, "LastBlock")
+	defer func() { endSpan(span, err) }()
+	b, err = s.Source.LastBlock(ctx)
+	if err == nil {
+		span.SetAttributes(blockAttributes(b)...)
+	}
+	return b, err
+}
+
+func blockAttributes(b *client.Block) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("block.id", b.ID.String()),
+		attribute.Int64("block.height", int64(b.Height)),
+		attribute.Int("block.transactions", len(b.Transactions)),
+	}
+}
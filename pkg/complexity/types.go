@@ -0,0 +1,73 @@
+package complexity
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/wavesplatform/gowaves/pkg/proto"
+)
+
+var transactionTypeNames = map[proto.TransactionType]string{
+	proto.GenesisTransaction:          "Genesis",
+	proto.PaymentTransaction:          "Payment",
+	proto.IssueTransaction:            "Issue",
+	proto.TransferTransaction:         "Transfer",
+	proto.ReissueTransaction:          "Reissue",
+	proto.BurnTransaction:             "Burn",
+	proto.ExchangeTransaction:         "Exchange",
+	proto.LeaseTransaction:            "Lease",
+	proto.LeaseCancelTransaction:      "LeaseCancel",
+	proto.CreateAliasTransaction:      "CreateAlias",
+	proto.MassTransferTransaction:     "MassTransfer",
+	proto.DataTransaction:             "Data",
+	proto.SetScriptTransaction:        "SetScript",
+	proto.SponsorshipTransaction:      "Sponsorship",
+	proto.SetAssetScriptTransaction:   "SetAssetScript",
+	proto.InvokeScriptTransaction:     "InvokeScript",
+	proto.UpdateAssetInfoTransaction:  "UpdateAssetInfo",
+	proto.EthereumMetamaskTransaction: "Ethereum",
+}
+
+// TransactionTypeName returns the human-readable name of the transaction type.
+func TransactionTypeName(t proto.TransactionType) string {
+	if n, ok := transactionTypeNames[t]; ok {
+		return n
+	}
+	return fmt.Sprintf("Unknown(%d)", t)
+}
+
+// ParseTransactionType returns the transaction type by its number, name or unique prefix of the name,
+// ignoring case, for example "16", "InvokeScript" or "invoke".
+func ParseTransactionType(s string) (proto.TransactionType, error) {
+	if n, err := strconv.ParseUint(s, 10, 8); err == nil {
+		if _, ok := transactionTypeNames[proto.TransactionType(n)]; ok {
+			return proto.TransactionType(n), nil
+		}
+		return 0, errors.Errorf("unknown transaction type %d", n)
+	}
+	var (
+		found   proto.TransactionType
+		matches int
+	)
+	for t, n := range transactionTypeNames {
+		name := strings.ToLower(n)
+		switch {
+		case name == strings.ToLower(s):
+			return t, nil
+		case strings.HasPrefix(name, strings.ToLower(s)):
+			found = t
+			matches++
+		}
+	}
+	switch matches {
+	case 0:
+		return 0, errors.Errorf("unknown transaction type '%s'", s)
+	case 1:
+		return found, nil
+	default:
+		return 0, errors.Errorf("ambiguous transaction type '%s'", s)
+	}
+}
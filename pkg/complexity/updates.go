@@ -0,0 +1,168 @@
+package complexity
+
+import (
+	"bytes"
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/wavesplatform/gowaves/pkg/client"
+	"github.com/wavesplatform/gowaves/pkg/grpc/generated/waves/events"
+	eg "github.com/wavesplatform/gowaves/pkg/grpc/generated/waves/events/grpc"
+	g "github.com/wavesplatform/gowaves/pkg/grpc/generated/waves/node/grpc"
+	"github.com/wavesplatform/gowaves/pkg/proto"
+	"google.golang.org/grpc"
+	pb "google.golang.org/protobuf/proto"
+)
+
+// UpdateType is the kind of change of the blockchain.
+type UpdateType int
+
+const (
+	BlockAppended UpdateType = iota
+	MicroBlockAppended
+	BlockRolledBack
+	MicroBlockRolledBack
+)
+
+// Update is the change of the blockchain received from the node's Blockchain Updates stream.
+type Update struct {
+	Type   UpdateType
+	Height uint64        // Height of the liquid block after the update
+	ID     proto.BlockID // ID of the liquid block after the update
+	// Liquid block with all the transactions of its microblocks after the update. Not set after the rollback of
+	// blocks and until the next block, because the stream doesn't contain the block the blockchain is rolled back to.
+	Block *client.Block
+	// Previous liquid block, which is final after a new block is appended on top of it.
+	// Not set if the previous block is unknown, which is the case for the first update or after the rollback of blocks.
+	Final               *client.Block
+	RemovedBlocks       int
+	RemovedTransactions int
+}
+
+// UpdatesSource receives changes of the blockchain from the node's Blockchain Updates gRPC API.
+type UpdatesSource struct {
+	conn *grpc.ClientConn
+	api  eg.BlockchainUpdatesApiClient
+}
+
+// NewUpdatesSource connects to the node's Blockchain Updates gRPC API at the given address within the timeout.
+func NewUpdatesSource(addr string, connectTimeout time.Duration) (*UpdatesSource, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout)
+	defer cancel()
+	conn, err := grpc.DialContext(ctx, addr, grpc.WithInsecure(), grpc.WithBlock())
+	if err != nil {
+		return nil, err
+	}
+	return &UpdatesSource{conn: conn, api: eg.NewBlockchainUpdatesApiClient(conn)}, nil
+}
+
+// Close closes the connection to the node.
+func (s *UpdatesSource) Close() {
+	_ = s.conn.Close()
+}
+
+// Subscribe streams changes of the blockchain starting from the block at the given height, calling the function
+// with every update until the context is canceled, the stream fails or the function returns an error.
+func (s *UpdatesSource) Subscribe(ctx context.Context, from uint64, fn func(Update) error) error {
+	stream, err := s.api.Subscribe(ctx, &eg.SubscribeRequest{FromHeight: int32(from)})
+	if err != nil {
+		return err
+	}
+	var liquid *client.Block
+	for {
+		ev, err := stream.Recv()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+		u, err := liquidUpdate(liquid, ev.GetUpdate())
+		if err != nil {
+			return errors.Wrapf(err, "invalid update at height %d", ev.GetUpdate().GetHeight())
+		}
+		liquid = u.Block
+		if err := fn(u); err != nil {
+			return err
+		}
+	}
+}
+
+// liquidUpdate applies the change of the blockchain to a copy of the liquid block, which may be nil if it's unknown.
+func liquidUpdate(liquid *client.Block, bu *events.BlockchainUpdated) (Update, error) {
+	id, err := proto.NewBlockIDFromBytes(bu.GetId())
+	if err != nil {
+		return Update{}, err
+	}
+	u := Update{Height: uint64(bu.GetHeight()), ID: id}
+	switch upd := bu.GetUpdate().(type) {
+	case *events.BlockchainUpdated_Append_:
+		switch body := upd.Append.GetBody().(type) {
+		case *events.BlockchainUpdated_Append_Block:
+			b, err := convertBlock(&g.BlockWithHeight{Block: body.Block.GetBlock(), Height: uint32(bu.GetHeight())})
+			if err != nil {
+				return Update{}, err
+			}
+			u.Type, u.Block, u.Final = BlockAppended, b, liquid
+		case *events.BlockchainUpdated_Append_MicroBlock:
+			u.Type = MicroBlockAppended
+			if liquid == nil {
+				return u, nil
+			}
+			var c proto.ProtobufConverter
+			mb, err := c.MicroBlock(body.MicroBlock.GetMicroBlock())
+			if err != nil {
+				return Update{}, err
+			}
+			b := *liquid
+			b.Transactions = append(append(client.TransactionsField(nil), liquid.Transactions...), mb.Transactions...)
+			b.TransactionCount += uint64(mb.TransactionCount)
+			for _, tx := range body.MicroBlock.GetMicroBlock().GetMicroBlock().GetTransactions() {
+				b.Blocksize += uint64(pb.Size(tx))
+			}
+			b.ID, b.Signature = id, mb.TotalResBlockSigField
+			u.Block = &b
+		default:
+			return Update{}, errors.New("empty append")
+		}
+	case *events.BlockchainUpdated_Rollback_:
+		u.RemovedBlocks = len(upd.Rollback.GetRemovedBlocks())
+		u.RemovedTransactions = len(upd.Rollback.GetRemovedTransactionIds())
+		if upd.Rollback.GetType() == events.BlockchainUpdated_Rollback_BLOCK {
+			u.Type = BlockRolledBack
+			return u, nil
+		}
+		u.Type = MicroBlockRolledBack
+		if liquid == nil {
+			return u, nil
+		}
+		b := *liquid
+		b.Transactions = nil
+		for _, tx := range liquid.Transactions {
+			if !removed(tx, b.Generator.Bytes()[1], upd.Rollback.GetRemovedTransactionIds()) {
+				b.Transactions = append(b.Transactions, tx)
+			}
+		}
+		b.TransactionCount = uint64(len(b.Transactions))
+		b.ID = id
+		u.Block = &b
+	default:
+		return Update{}, errors.New("unknown update")
+	}
+	return u, nil
+}
+
+// removed reports whether the ID of the transaction is one of the IDs.
+func removed(tx proto.Transaction, scheme byte, ids [][]byte) bool {
+	id, err := tx.GetID(scheme)
+	if err != nil {
+		return false
+	}
+	for _, r := range ids {
+		if bytes.Equal(id, r) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,81 @@
+package complexity
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+// UnconfirmedComplexity is the estimated complexity of transactions waiting in the node's UTX pool to be mined.
+type UnconfirmedComplexity struct {
+	Height             uint64           `json:"height"` // Height of the last block at the moment of the request
+	Transactions       []Complexity     `json:"transactions"`
+	Complexity         int              `json:"complexity"`
+	VerifierComplexity int              `json:"verifierComplexity"` // Complexity of verifiers of senders' accounts
+	AssetsComplexity   int              `json:"assetsComplexity"`   // Complexity of scripts of smart assets
+	Limit              int              `json:"limit"`              // Limit of the next block
+	Utilization        float64          `json:"utilization"`
+	DApps              []DAppComplexity `json:"dApps"`
+}
+
+// Unconfirmed estimates complexities of the unconfirmed transactions. The transactions are not executed yet, so
+// the complexity of a transaction is estimated by complexities of the scripts it triggers, which is an upper bound
+// of the complexity they spend, without calls of other dApps. Complexities of scripts are reported by the node,
+// or estimated locally if it's set in the options.
+func (a *Analyzer) Unconfirmed(ctx context.Context) (*UnconfirmedComplexity, error) {
+	if err := a.limits.load(ctx, a); err != nil {
+		return nil, errors.Wrap(err, "failed to get features activation status")
+	}
+	h, err := a.src.Height(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get blockchain height")
+	}
+	scheme := a.opts.Scheme
+	if scheme == 0 {
+		if scheme, err = a.Scheme(ctx); err != nil {
+			return nil, err
+		}
+	}
+	if a.cl == nil {
+		return nil, errors.New("unconfirmed transactions are available only from the node's API")
+	}
+	txs, _, err := a.cl.Transactions.Unconfirmed(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get unconfirmed transactions")
+	}
+	version := 0
+	if a.opts.Estimate {
+		version = a.limits.estimator(h + 1)
+	}
+	e := a.newEstimator(scheme, version)
+	complexities := make([]Complexity, 0, len(txs))
+	for _, tx := range txs {
+		if !a.included(tx.GetTypeInfo().Type) {
+			continue
+		}
+		c, err := e.estimate(ctx, tx)
+		if err != nil {
+			return nil, err
+		}
+		if len(a.opts.Addresses) == 0 || a.matches(*c) {
+			complexities = append(complexities, *c)
+		}
+	}
+	if a.opts.ScriptVersions {
+		if err := e.annotate(ctx, complexities, a.limits.estimator(h+1)); err != nil {
+			return nil, err
+		}
+	}
+	total := totalComplexity(complexities)
+	limit := a.limits.limit(h + 1)
+	return &UnconfirmedComplexity{
+		Height:             h,
+		Transactions:       complexities,
+		Complexity:         total,
+		VerifierComplexity: verifierComplexity(complexities),
+		AssetsComplexity:   assetsComplexity(complexities),
+		Limit:              limit,
+		Utilization:        utilization(total, limit),
+		DApps:              dAppsComplexities(complexities),
+	}, nil
+}
@@ -0,0 +1,116 @@
+package complexity
+
+import (
+	"context"
+
+	"github.com/mr-tron/base58"
+	"github.com/pkg/errors"
+	"github.com/wavesplatform/gowaves/pkg/client"
+	"github.com/wavesplatform/gowaves/pkg/crypto"
+	"github.com/wavesplatform/gowaves/pkg/proto"
+)
+
+// ErrInvalidBlock is returned for a block which contents don't match its signature or ID.
+var ErrInvalidBlock = errors.New("invalid block")
+
+// verifiedSource checks the signature and the ID of every block retrieved from the underlying source.
+type verifiedSource struct {
+	Source
+	scheme proto.Scheme // Chain ID of the blockchain, taken from the addresses of generators if zero
+}
+
+func (s verifiedSource) Block(ctx context.Context, id proto.BlockID) (*client.Block, error) {
+	return s.verified(s.Source.Block(ctx, id))
+}
+
+func (s verifiedSource) BlockAt(ctx context.Context, height uint64) (*client.Block, error) {
+	return s.verified(s.Source.BlockAt(ctx, height))
+}
+
+func (s verifiedSource) LastBlock(ctx context.Context) (*client.Block, error) {
+	return s.verified(s.Source.LastBlock(ctx))
+}
+
+func (s verifiedSource) verified(b *client.Block, err error) (*client.Block, error) {
+	if err != nil {
+		return nil, err
+	}
+	scheme := s.scheme
+	if scheme == 0 {
+		scheme = b.Generator.Bytes()[1]
+	}
+	if err := verifyBlock(b, scheme); err != nil {
+		return nil, errors.Wrapf(ErrInvalidBlock, "block '%s' at height %d: %v", b.ID.String(), b.Height, err)
+	}
+	return b, nil
+}
+
+// verifyBlock rebuilds the block from the retrieved headers and transactions and checks that the block is signed
+// by its generator and has the reported ID. The signature of blocks before version 5 covers the transactions,
+// later blocks are signed with the root of the transactions' Merkle tree, which is calculated from the transactions.
+// The genesis block is not verified.
+func verifyBlock(b *client.Block, scheme proto.Scheme) error {
+	if b.Version == uint64(proto.GenesisBlockVersion) {
+		return nil // The genesis block is defined by the settings of the network rather than forged by a generator
+	}
+	pk, err := crypto.NewPublicKeyFromBase58(b.GeneratorPublicKey)
+	if err != nil {
+		return errors.Wrap(err, "invalid generator public key")
+	}
+	gen, err := proto.NewAddressFromPublicKey(scheme, pk)
+	if err != nil {
+		return err
+	}
+	if gen != b.Generator {
+		return errors.Errorf("generator '%s' doesn't match public key '%s'", b.Generator.String(), b.GeneratorPublicKey)
+	}
+	gs, err := base58.Decode(b.NxtConsensus.GenerationSignature)
+	if err != nil {
+		return errors.Wrap(err, "invalid generation signature")
+	}
+	features := make([]int16, len(b.Features))
+	for i, f := range b.Features {
+		features[i] = int16(f)
+	}
+	txs := proto.Transactions(b.Transactions)
+	nxt := proto.NxtConsensus{BaseTarget: b.NxtConsensus.BaseTarget, GenSignature: gs}
+	// The transactions root of the rebuilt block is calculated from the retrieved transactions
+	pb, err := proto.CreateBlock(txs, b.Timestamp, b.Reference, pk, nxt, proto.BlockVersion(b.Version), features, b.DesiredReward, scheme)
+	if err != nil {
+		return errors.Wrap(err, "failed to rebuild block")
+	}
+	if err := verifyTransactions(b, pb); err != nil {
+		return err
+	}
+	pb.BlockSignature = b.Signature
+	ok, err := pb.VerifySignature(scheme)
+	if err != nil {
+		return errors.Wrap(err, "failed to verify signature")
+	}
+	if !ok {
+		return errors.New("signature doesn't match the generator and the contents")
+	}
+	if err := pb.GenerateBlockID(scheme); err != nil {
+		return err
+	}
+	if pb.ID != b.ID {
+		return errors.Errorf("ID doesn't match the contents, expected '%s'", pb.ID.String())
+	}
+	return nil
+}
+
+// verifyTransactions checks that the retrieved transactions are exactly the ones the block commits to: their number
+// matches the header, and for blocks of version 5 and later the root of the Merkle tree of the transactions, as
+// calculated for the rebuilt block, matches the transactions root of the header, which is covered by the signature.
+func verifyTransactions(b *client.Block, rebuilt *proto.Block) error {
+	if int(b.TransactionCount) != len(b.Transactions) {
+		return errors.Errorf("%d transactions given instead of %d in the header", len(b.Transactions), b.TransactionCount)
+	}
+	if b.Version < uint64(proto.ProtobufBlockVersion) {
+		return nil
+	}
+	if root := base58.Encode(rebuilt.TransactionsRoot); root != b.TransactionsRoot {
+		return errors.Errorf("transactions root '%s' of the header doesn't match root '%s' of the transactions", b.TransactionsRoot, root)
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package complexity
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/mr-tron/base58"
+	"github.com/wavesplatform/gowaves/pkg/client"
+	"github.com/wavesplatform/gowaves/pkg/crypto"
+	"github.com/wavesplatform/gowaves/pkg/proto"
+)
+
+// signedBlock returns the block of the version with two transfers signed by the generator as the node reports it.
+func signedBlock(t *testing.T, version proto.BlockVersion) *client.Block {
+	t.Helper()
+	sk, pk, err := crypto.GenerateKeyPair([]byte("generator"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	generator, err := proto.NewAddressFromPublicKey(proto.MainNetScheme, pk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const timestamp = 1_700_000_000_000
+	txs := make(proto.Transactions, 2)
+	for i := range txs {
+		tx := proto.NewUnsignedTransferWithProofs(2, pk, proto.NewOptionalAssetWaves(), proto.NewOptionalAssetWaves(),
+			timestamp-uint64(i), 100_000_000, 100_000, proto.NewRecipientFromAddress(generator), nil)
+		if err := tx.Sign(proto.MainNetScheme, sk); err != nil {
+			t.Fatal(err)
+		}
+		txs[i] = tx
+	}
+	nxt := proto.NxtConsensus{BaseTarget: 100, GenSignature: []byte("generation signature")}
+	var features []int16
+	if version >= proto.NgBlockVersion {
+		features = []int16{14, 15}
+	}
+	pb, err := proto.CreateBlock(txs, timestamp, proto.NewBlockIDFromSignature(crypto.Signature{1}), pk, nxt, version, features, 600_000_000, proto.MainNetScheme)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pb.Sign(proto.MainNetScheme, sk); err != nil {
+		t.Fatal(err)
+	}
+	if err := pb.GenerateBlockID(proto.MainNetScheme); err != nil {
+		t.Fatal(err)
+	}
+	b := &client.Block{
+		Headers: client.Headers{
+			Version:   uint64(version),
+			Timestamp: timestamp,
+			Reference: pb.Parent,
+			NxtConsensus: client.NxtConsensus{
+				BaseTarget:          nxt.BaseTarget,
+				GenerationSignature: base58.Encode(nxt.GenSignature),
+			},
+			DesiredReward:      pb.RewardVote,
+			Generator:          generator,
+			GeneratorPublicKey: pk.String(),
+			Signature:          pb.BlockSignature,
+			TransactionCount:   uint64(len(txs)),
+			Height:             1000,
+			ID:                 pb.ID,
+		},
+		Transactions: client.TransactionsField(txs),
+	}
+	for _, f := range features {
+		b.Features = append(b.Features, uint64(f))
+	}
+	if version >= proto.ProtobufBlockVersion {
+		b.TransactionsRoot = base58.Encode(pb.TransactionsRoot)
+	}
+	return b
+}
+
+func TestVerifyBlock(t *testing.T) {
+	for _, version := range []proto.BlockVersion{proto.PlainBlockVersion, proto.NgBlockVersion, proto.RewardBlockVersion, proto.ProtobufBlockVersion} {
+		for _, test := range []struct {
+			name   string
+			tamper func(b *client.Block)
+			valid  bool
+		}{
+			{name: "valid", tamper: func(*client.Block) {}, valid: true},
+			{name: "signature", tamper: func(b *client.Block) { b.Signature[0] ^= 1 }},
+			{name: "id", tamper: func(b *client.Block) { b.ID = proto.NewBlockIDFromSignature(crypto.Signature{2}) }},
+			{name: "timestamp", tamper: func(b *client.Block) { b.Timestamp++ }},
+			{name: "base target", tamper: func(b *client.Block) { b.NxtConsensus.BaseTarget++ }},
+			{name: "generator", tamper: func(b *client.Block) { b.Generator[2] ^= 1 }},
+			{name: "public key", tamper: func(b *client.Block) { b.GeneratorPublicKey = "invalid" }},
+			{name: "missing transaction", tamper: func(b *client.Block) { b.Transactions = b.Transactions[:1] }},
+			{name: "replaced transactions", tamper: func(b *client.Block) {
+				b.Transactions[0], b.Transactions[1] = b.Transactions[1], b.Transactions[0]
+			}},
+			{name: "transaction count", tamper: func(b *client.Block) { b.TransactionCount++ }},
+		} {
+			t.Run(fmt.Sprintf("v%d %s", version, test.name), func(t *testing.T) {
+				b := signedBlock(t, version)
+				test.tamper(b)
+				err := verifyBlock(b, proto.MainNetScheme)
+				if test.valid && err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				if !test.valid && err == nil {
+					t.Errorf("expected error")
+				}
+			})
+		}
+	}
+}
+
+func TestVerifyBlockTransactionsRoot(t *testing.T) {
+	b := signedBlock(t, proto.ProtobufBlockVersion)
+	b.TransactionsRoot = base58.Encode(make([]byte, crypto.DigestSize))
+	if err := verifyBlock(b, proto.MainNetScheme); err == nil {
+		t.Errorf("expected error")
+	}
+}
+
+func TestVerifyGenesisBlock(t *testing.T) {
+	b := &client.Block{Headers: client.Headers{Version: uint64(proto.GenesisBlockVersion)}}
+	if err := verifyBlock(b, proto.MainNetScheme); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const progressInterval = 2 * time.Second
+
+// progress logs the number of processed items of a long operation. Nothing is logged
+// if the operation completes within the interval.
+type progress struct {
+	interval time.Duration
+
+	mu     sync.Mutex
+	last   time.Time
+	blocks uint64 // Number of processed blocks of a range
+	total  uint64 // Number of blocks in a range, zero if a single block is processed
+}
+
+func newProgress() *progress {
+	return &progress{interval: progressInterval, last: time.Now()}
+}
+
+// due reports whether the interval has passed since the last report, the caller must hold the lock.
+func (p *progress) due() bool {
+	if time.Since(p.last) < p.interval {
+		return false
+	}
+	p.last = time.Now()
+	return true
+}
+
+// transactions reports the number of processed transactions of the block.
+func (p *progress) transactions(height uint64, processed, total int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.due() {
+		return
+	}
+	if p.total > 0 {
+		slog.Info("Processing blocks", "processed", p.blocks, "total", p.total, "height", height, "processedTransactions", processed, "totalTransactions", total)
+		return
+	}
+	slog.Info("Processing block", "height", height, "processedTransactions", processed, "totalTransactions", total)
+}
+
+// block counts the processed block of the range of the given number of blocks.
+func (p *progress) block(total uint64) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.blocks++
+	p.total = total
+	if p.due() {
+		slog.Info("Processing blocks", "processed", p.blocks, "total", p.total)
+	}
+}
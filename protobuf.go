@@ -0,0 +1,190 @@
+package main
+
+import (
+	"io"
+	"math"
+
+	"github.com/alexeykiselev/waves-block-complexity/pkg/complexity"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Numbers of fields of Result message of proto/complexity.proto.
+const (
+	protoResultBlock   protowire.Number = 1
+	protoResultSummary protowire.Number = 2
+	protoResultStats   protowire.Number = 3
+)
+
+// protoPrinter writes every result as a length-delimited Result message of proto/complexity.proto as soon as it is
+// processed. Messages are encoded directly with protowire, so the encoding must follow the schema.
+type protoPrinter struct {
+	w io.Writer
+}
+
+func (p *protoPrinter) block(b complexity.BlockComplexity) error {
+	return p.write(protoResultBlock, protoBlock(b))
+}
+
+func (p *protoPrinter) summary(b complexity.BlockComplexity) error {
+	return p.write(protoResultSummary, protoBlock(b))
+}
+
+func (p *protoPrinter) stats(s complexity.RangeStats) error {
+	return p.write(protoResultStats, protoStats(s))
+}
+
+// write writes the Result message with the field of the oneof set to the message, prefixed with its length.
+func (p *protoPrinter) write(n protowire.Number, m []byte) error {
+	r := protowire.AppendBytes(protowire.AppendTag(nil, n, protowire.BytesType), m)
+	_, err := p.w.Write(protowire.AppendBytes(nil, r))
+	return err
+}
+
+func (p *protoPrinter) flush() error {
+	return nil
+}
+
+func (p *protoPrinter) streaming() bool {
+	return true
+}
+
+func protoBlock(b complexity.BlockComplexity) []byte {
+	var m []byte
+	m = protoString(m, 1, b.ID.String())
+	m = protoVarint(m, 2, b.Height)
+	m = protoVarint(m, 3, b.Timestamp)
+	m = protoString(m, 4, b.Generator.String())
+	for _, c := range b.Transactions {
+		m = protoMessage(m, 5, protoTransaction(c))
+	}
+	m = protoInt(m, 6, b.Complexity)
+	m = protoInt(m, 7, b.FailedTransactions)
+	m = protoInt(m, 8, b.FailedComplexity)
+	m = protoInt(m, 9, b.VerifierComplexity)
+	m = protoInt(m, 10, b.AssetsComplexity)
+	m = protoInt(m, 11, b.Limit)
+	m = protoDouble(m, 12, b.Utilization)
+	m = protoVarint(m, 13, b.Fees)
+	m = protoDouble(m, 14, b.FeePerComplexity)
+	m = protoVarint(m, 15, b.Size)
+	m = protoInt(m, 16, b.TransactionCount)
+	if b.Estimated {
+		m = protoVarint(m, 17, 1)
+	}
+	m = protoString(m, 18, b.Note)
+	m = protoVarint(m, 19, b.BaseTarget)
+	m = protoInt(m, 20, b.UnknownTransactions)
+	return m
+}
+
+func protoTransaction(c complexity.Complexity) []byte {
+	var m []byte
+	m = protoString(m, 1, c.ID.String())
+	m = protoInt(m, 2, int(c.Type))
+	m = protoString(m, 3, c.Sender.String())
+	m = protoString(m, 4, c.ApplicationStatus)
+	m = protoInt(m, 5, c.SpentComplexity)
+	m = protoInt(m, 6, c.VerifierComplexity)
+	m = protoVarint(m, 7, c.Fee)
+	m = protoString(m, 8, c.FeeAssetID)
+	if c.Invocation != nil {
+		m = protoMessage(m, 9, protoInvocation(*c.Invocation))
+	}
+	for _, sa := range c.SmartAssets {
+		var a []byte
+		a = protoString(a, 1, sa.Asset.String())
+		a = protoInt(a, 2, sa.Complexity)
+		m = protoMessage(m, 10, a)
+	}
+	if c.Unknown {
+		m = protoVarint(m, 11, 1)
+	}
+	return m
+}
+
+func protoInvocation(inv complexity.Invocation) []byte {
+	var m []byte
+	m = protoString(m, 1, inv.DApp)
+	m = protoString(m, 2, inv.Function)
+	for _, c := range inv.Invocations {
+		m = protoMessage(m, 3, protoInvocation(c))
+	}
+	m = protoInt(m, 4, inv.RideVersion)
+	m = protoInt(m, 5, inv.Estimator)
+	m = protoInt(m, 6, inv.Complexity)
+	return m
+}
+
+func protoStats(s complexity.RangeStats) []byte {
+	var m []byte
+	m = protoVarint(m, 1, s.Blocks)
+	m = protoVarint(m, 2, s.Transactions)
+	m = protoInt(m, 3, s.Complexity)
+	m = protoVarint(m, 4, s.FailedTransactions)
+	m = protoInt(m, 5, s.FailedComplexity)
+	m = protoInt(m, 6, s.VerifierComplexity)
+	m = protoInt(m, 7, s.AssetsComplexity)
+	m = protoInt(m, 8, s.AverageComplexity)
+	m = protoInt(m, 9, s.MaxComplexity)
+	m = protoVarint(m, 10, s.MaxHeight)
+	m = protoDouble(m, 11, s.MaxUtilization)
+	m = protoDouble(m, 12, s.AverageUtilization)
+	for _, t := range s.Types {
+		var tm []byte
+		tm = protoInt(tm, 1, int(t.Type))
+		tm = protoInt(tm, 2, t.Transactions)
+		tm = protoInt(tm, 3, t.Complexity)
+		m = protoMessage(m, 13, tm)
+	}
+	for _, d := range s.DApps {
+		var dm []byte
+		dm = protoString(dm, 1, d.DApp)
+		dm = protoInt(dm, 2, d.Transactions)
+		dm = protoInt(dm, 3, d.Complexity)
+		m = protoMessage(m, 14, dm)
+	}
+	for _, g := range s.Generators {
+		var gm []byte
+		gm = protoString(gm, 1, g.Generator.String())
+		gm = protoInt(gm, 2, g.Blocks)
+		gm = protoInt(gm, 3, g.Complexity)
+		gm = protoInt(gm, 4, g.AverageComplexity)
+		gm = protoDouble(gm, 5, g.AverageUtilization)
+		m = protoMessage(m, 15, gm)
+	}
+	m = protoVarint(m, 16, s.UnknownTransactions)
+	return m
+}
+
+// Functions appending fields omit the fields with default values, as proto3 encoders do.
+
+func protoVarint(b []byte, n protowire.Number, v uint64) []byte {
+	if v == 0 {
+		return b
+	}
+	return protowire.AppendVarint(protowire.AppendTag(b, n, protowire.VarintType), v)
+}
+
+// protoInt appends the value of int32 or int64 field.
+func protoInt(b []byte, n protowire.Number, v int) []byte {
+	return protoVarint(b, n, uint64(int64(v)))
+}
+
+func protoDouble(b []byte, n protowire.Number, v float64) []byte {
+	if v == 0 {
+		return b
+	}
+	return protowire.AppendFixed64(protowire.AppendTag(b, n, protowire.Fixed64Type), math.Float64bits(v))
+}
+
+func protoString(b []byte, n protowire.Number, s string) []byte {
+	if s == "" {
+		return b
+	}
+	return protowire.AppendString(protowire.AppendTag(b, n, protowire.BytesType), s)
+}
+
+// protoMessage appends the embedded message, which is appended even if it's empty.
+func protoMessage(b []byte, n protowire.Number, m []byte) []byte {
+	return protowire.AppendBytes(protowire.AppendTag(b, n, protowire.BytesType), m)
+}
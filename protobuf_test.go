@@ -0,0 +1,214 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/alexeykiselev/waves-block-complexity/pkg/complexity"
+	"github.com/bufbuild/protocompile"
+	"github.com/wavesplatform/gowaves/pkg/crypto"
+	"github.com/wavesplatform/gowaves/pkg/proto"
+	"google.golang.org/protobuf/encoding/protodelim"
+	pb "google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// schema returns the descriptor of proto/complexity.proto.
+func schema(t *testing.T) protoreflect.FileDescriptor {
+	t.Helper()
+	c := protocompile.Compiler{Resolver: &protocompile.SourceResolver{ImportPaths: []string{"proto"}}}
+	files, err := c.Compile(context.Background(), "complexity.proto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files[0]
+}
+
+// decode unmarshals the encoded message of the schema by the message name.
+func decode(t *testing.T, fd protoreflect.FileDescriptor, name protoreflect.Name, b []byte) *dynamicpb.Message {
+	t.Helper()
+	m := dynamicpb.NewMessage(fd.Messages().ByName(name))
+	if err := pb.Unmarshal(b, m); err != nil {
+		t.Fatal(err)
+	}
+	if len(m.GetUnknown()) != 0 {
+		t.Fatalf("%s has fields missing in the schema", name)
+	}
+	return m
+}
+
+// field returns the value of the field of the message by the field name.
+func field(m protoreflect.Message, name protoreflect.Name) protoreflect.Value {
+	return m.Get(m.Descriptor().Fields().ByName(name))
+}
+
+func testBlock(t *testing.T) complexity.BlockComplexity {
+	t.Helper()
+	id, err := proto.NewBlockIDFromBase58("FSH8eAAzZNqnG8xgTZtz5xuLqXySsXgAjmFEC25hXMbEufiGjqWPnGCZFt6gLiVLJny16ipxRNAkkzjjhqTjBE2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	generator, err := proto.NewAddressFromString("3P7Q8HMeH7YfScBXg27DWpT4VeZfudzDyYD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return complexity.BlockComplexity{
+		ID: id, Height: 4_000_000, Timestamp: 1_700_000_000_000, Generator: generator, Size: 1234, BaseTarget: 100,
+		TransactionCount: 3, Complexity: 2600, FailedTransactions: 1, FailedComplexity: 100, UnknownTransactions: 1,
+		VerifierComplexity: 200, AssetsComplexity: 300, Limit: 2_500_000, Utilization: 0.104, Fees: 1_500_000,
+		FeePerComplexity: 576.9, Estimated: true, Note: "note",
+		Transactions: []complexity.Complexity{
+			{
+				ID: crypto.MustDigestFromBase58("2a9MXyrYkCQBir4FHYZxcqiAQY8Rtz1tcWLdrWkNtTo9"), Type: proto.InvokeScriptTransaction,
+				Sender: generator, ApplicationStatus: complexity.StatusScriptExecutionFailed, SpentComplexity: 2600,
+				VerifierComplexity: 200, Fee: 500_000, FeeAssetID: "asset",
+				Invocation: &complexity.Invocation{DApp: "3PExampleDApp", Function: "swap", RideVersion: 6, Estimator: 4,
+					Invocations: []complexity.Invocation{{DApp: "3PNestedDApp", Function: "default", Complexity: 100}}},
+				SmartAssets: []complexity.SmartAsset{{Asset: crypto.MustDigestFromBase58("2a9MXyrYkCQBir4FHYZxcqiAQY8Rtz1tcWLdrWkNtTo9"), Complexity: 300}},
+			},
+			{Type: proto.TransferTransaction, Unknown: true},
+		},
+	}
+}
+
+func TestProtoBlock(t *testing.T) {
+	fd := schema(t)
+	b := testBlock(t)
+	m := decode(t, fd, "Block", protoBlock(b))
+	for _, test := range []struct {
+		name     protoreflect.Name
+		expected interface{}
+	}{
+		{"id", b.ID.String()},
+		{"height", b.Height},
+		{"timestamp", b.Timestamp},
+		{"generator", b.Generator.String()},
+		{"complexity", int64(b.Complexity)},
+		{"failed_transactions", int32(b.FailedTransactions)},
+		{"failed_complexity", int64(b.FailedComplexity)},
+		{"verifier_complexity", int64(b.VerifierComplexity)},
+		{"assets_complexity", int64(b.AssetsComplexity)},
+		{"limit", int64(b.Limit)},
+		{"utilization", b.Utilization},
+		{"fees", b.Fees},
+		{"fee_per_complexity", b.FeePerComplexity},
+		{"size", b.Size},
+		{"transaction_count", int32(b.TransactionCount)},
+		{"estimated", true},
+		{"note", b.Note},
+		{"base_target", b.BaseTarget},
+		{"unknown_transactions", int32(b.UnknownTransactions)},
+	} {
+		if v := field(m, test.name).Interface(); v != test.expected {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, v)
+		}
+	}
+	txs := field(m, "transactions").List()
+	if txs.Len() != len(b.Transactions) {
+		t.Fatalf("expected %d transactions, got %d", len(b.Transactions), txs.Len())
+	}
+	tx, c := txs.Get(0).Message(), b.Transactions[0]
+	for _, test := range []struct {
+		name     protoreflect.Name
+		expected interface{}
+	}{
+		{"id", c.ID.String()},
+		{"type", int32(c.Type)},
+		{"sender", c.Sender.String()},
+		{"application_status", c.ApplicationStatus},
+		{"spent_complexity", int64(c.SpentComplexity)},
+		{"verifier_complexity", int64(c.VerifierComplexity)},
+		{"fee", c.Fee},
+		{"fee_asset_id", c.FeeAssetID},
+		{"unknown", false},
+	} {
+		if v := field(tx, test.name).Interface(); v != test.expected {
+			t.Errorf("transaction %s: expected %v, got %v", test.name, test.expected, v)
+		}
+	}
+	inv := field(tx, "invocation").Message()
+	if dApp, function := field(inv, "dapp").String(), field(inv, "function").String(); dApp != "3PExampleDApp" || function != "swap" {
+		t.Errorf("expected invocation of 3PExampleDApp.swap, got %s.%s", dApp, function)
+	}
+	if n := field(inv, "invocations").List(); n.Len() != 1 || field(n.Get(0).Message(), "dapp").String() != "3PNestedDApp" ||
+		field(n.Get(0).Message(), "complexity").Int() != 100 {
+		t.Errorf("expected nested invocation of 3PNestedDApp with complexity 100")
+	}
+	if assets := field(tx, "smart_assets").List(); assets.Len() != 1 || field(assets.Get(0).Message(), "complexity").Int() != 300 {
+		t.Errorf("expected smart asset with complexity 300")
+	}
+	if !field(txs.Get(1).Message(), "unknown").Bool() {
+		t.Errorf("expected transaction with unknown complexity")
+	}
+}
+
+func TestProtoStats(t *testing.T) {
+	fd := schema(t)
+	generator, err := proto.NewAddressFromString("3P7Q8HMeH7YfScBXg27DWpT4VeZfudzDyYD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := complexity.RangeStats{
+		Blocks: 10, Transactions: 20, Complexity: 3000, FailedTransactions: 2, FailedComplexity: 100,
+		AverageComplexity: 300, MaxComplexity: 1000, MaxHeight: 5, MaxUtilization: 0.04, AverageUtilization: 0.012,
+		UnknownTransactions: 3,
+		Types:               []complexity.TypeComplexity{{Type: proto.InvokeScriptTransaction, Transactions: 20, Complexity: 3000}},
+		DApps:               []complexity.DAppComplexity{{DApp: "3PExampleDApp", Transactions: 20, Complexity: 3000}},
+		Generators:          []complexity.GeneratorComplexity{{Generator: generator, Blocks: 10, Complexity: 3000}},
+	}
+	m := decode(t, fd, "RangeStats", protoStats(s))
+	for _, test := range []struct {
+		name     protoreflect.Name
+		expected interface{}
+	}{
+		{"blocks", s.Blocks},
+		{"transactions", s.Transactions},
+		{"complexity", int64(s.Complexity)},
+		{"max_height", s.MaxHeight},
+		{"average_utilization", s.AverageUtilization},
+		{"unknown_transactions", s.UnknownTransactions},
+	} {
+		if v := field(m, test.name).Interface(); v != test.expected {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, v)
+		}
+	}
+	for _, name := range []protoreflect.Name{"types", "dapps", "generators"} {
+		if n := field(m, name).List().Len(); n != 1 {
+			t.Errorf("%s: expected 1 element, got %d", name, n)
+		}
+	}
+}
+
+func TestProtoPrinter(t *testing.T) {
+	fd := schema(t)
+	b := testBlock(t)
+	buf := new(bytes.Buffer)
+	p := &protoPrinter{w: buf}
+	if err := p.block(b); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.summary(b); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.stats(complexity.RangeStats{Blocks: 1}); err != nil {
+		t.Fatal(err)
+	}
+	r := bufio.NewReader(buf)
+	desc := fd.Messages().ByName("Result")
+	for _, expected := range []protoreflect.Name{"block", "summary", "stats"} {
+		m := dynamicpb.NewMessage(desc)
+		if err := protodelim.UnmarshalFrom(r, m); err != nil {
+			t.Fatal(err)
+		}
+		f := m.WhichOneof(desc.Oneofs().ByName("result"))
+		if f == nil || f.Name() != expected {
+			t.Fatalf("expected result %s, got %v", expected, f)
+		}
+	}
+	if r.Buffered() != 0 {
+		t.Errorf("unexpected data after results")
+	}
+}
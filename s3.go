@@ -0,0 +1,204 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultS3Endpoint = "https://s3.amazonaws.com"
+	defaultS3Region   = "us-east-1"
+	s3UploadTimeout   = 5 * time.Minute
+	basicTimeFormat   = "20060102T150405Z" // ISO 8601 basic format of time in UTC
+)
+
+// s3Uploader puts objects to the bucket of S3-compatible storage using path-style URLs and requests signed with
+// AWS Signature Version 4.
+type s3Uploader struct {
+	endpoint  *url.URL
+	region    string
+	bucket    string
+	prefix    string // Prepended to names of objects as is, so it should end with slash to denote a folder
+	accessKey string
+	secretKey string
+	cl        *http.Client
+}
+
+// newS3Uploader creates the uploader, credentials are taken from AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY
+// environment variables if not given.
+func newS3Uploader(endpoint, region, bucket, prefix, accessKey, secretKey string) (*s3Uploader, error) {
+	if !strings.Contains(endpoint, "//") {
+		endpoint = "https://" + endpoint
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid endpoint")
+	}
+	if u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, errors.Errorf("invalid endpoint '%s'", endpoint)
+	}
+	if accessKey == "" {
+		accessKey = os.Getenv("AWS_ACCESS_KEY_ID")
+	}
+	if secretKey == "" {
+		secretKey = os.Getenv("AWS_SECRET_ACCESS_KEY")
+	}
+	if accessKey == "" || secretKey == "" {
+		return nil, errors.New("access key and secret key are required")
+	}
+	return &s3Uploader{
+		endpoint:  u,
+		region:    region,
+		bucket:    bucket,
+		prefix:    prefix,
+		accessKey: accessKey,
+		secretKey: secretKey,
+		cl:        &http.Client{Timeout: s3UploadTimeout},
+	}, nil
+}
+
+// upload puts the contents of the file to the object with the name prefixed by the uploader's prefix.
+func (u *s3Uploader) upload(name, file, contentType string) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	key := u.prefix + name
+	target := *u.endpoint
+	target.Path = path.Join("/", u.endpoint.Path, u.bucket, key)
+	req, err := http.NewRequest(http.MethodPut, target.String(), bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	u.sign(req, data, time.Now().UTC())
+	resp, err := u.cl.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return errors.Errorf("unexpected status %d: %s", resp.StatusCode, bytes.TrimSpace(msg))
+	}
+	return nil
+}
+
+// sign adds the headers of AWS Signature Version 4 to the request, all headers of the request are signed.
+func (u *s3Uploader) sign(req *http.Request, payload []byte, now time.Time) {
+	sum := sha256.Sum256(payload)
+	payloadHash := hex.EncodeToString(sum[:])
+	amzDate := now.Format(basicTimeFormat)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for n, v := range req.Header {
+		headers[strings.ToLower(n)] = strings.TrimSpace(strings.Join(v, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for n := range headers {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, n := range names {
+		fmt.Fprintf(&canonicalHeaders, "%s:%s\n", n, headers[n])
+	}
+	signedHeaders := strings.Join(names, ";")
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		awsEscape(req.URL.Path),
+		"", // Requests have no query
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	day := now.Format("20060102")
+	scope := day + "/" + u.region + "/s3/aws4_request"
+	crSum := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(crSum[:])
+	key := hmacSHA256([]byte("AWS4"+u.secretKey), day)
+	key = hmacSHA256(key, u.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		u.accessKey, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// awsEscape escapes the path as AWS requires: every byte except unreserved characters and slashes is encoded.
+func awsEscape(p string) string {
+	var sb strings.Builder
+	for i := 0; i < len(p); i++ {
+		c := p[i]
+		if c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || strings.IndexByte("-._~/", c) >= 0 {
+			sb.WriteByte(c)
+		} else {
+			fmt.Fprintf(&sb, "%%%02X", c)
+		}
+	}
+	return sb.String()
+}
+
+// reportName returns the name of the object for the report in the format, the base name of the output file is used
+// if it's given, otherwise the name is made of the time of the run.
+func reportName(output, format string, now time.Time) string {
+	if output != "" {
+		return path.Base(output)
+	}
+	ext := format
+	switch format {
+	case textFormat, influxFormat, templateFormat:
+		ext = "txt"
+	case markdownFormat:
+		ext = "md"
+	case protoFormat:
+		ext = "pb"
+	}
+	return "report-" + now.UTC().Format(basicTimeFormat) + "." + ext
+}
+
+// reportContentType returns the media type of the report in the format.
+func reportContentType(format string) string {
+	switch format {
+	case jsonFormat:
+		return "application/json"
+	case ndjsonFormat:
+		return "application/x-ndjson"
+	case csvFormat:
+		return "text/csv; charset=utf-8"
+	case markdownFormat:
+		return "text/markdown; charset=utf-8"
+	case htmlFormat:
+		return "text/html; charset=utf-8"
+	case xlsxFormat:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	case parquetFormat:
+		return "application/vnd.apache.parquet"
+	case protoFormat:
+		return "application/x-protobuf"
+	default:
+		return "text/plain; charset=utf-8"
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// scheduleTimePlaceholder is replaced in the name of the output file with the time of the run.
+const scheduleTimePlaceholder = "{time}"
+
+// runSchedule analyzes the blocks created within the window preceding every time of the schedule. A failed run
+// is logged and doesn't stop the schedule.
+func runSchedule(ctx context.Context, o *options, args []string) error {
+	if err := noArguments(args); err != nil {
+		return err
+	}
+	s, err := parseCron(o.cron)
+	if err != nil {
+		slog.Error("Invalid parameters", "error", err)
+		return err
+	}
+	if o.window <= 0 {
+		err := errors.Errorf("invalid window %s", o.window)
+		slog.Error("Invalid parameters", "error", err)
+		return err
+	}
+	for {
+		next := s.next(time.Now())
+		if next.IsZero() {
+			err := errors.Errorf("schedule '%s' never matches", o.cron)
+			slog.Error("Invalid parameters", "error", err)
+			return err
+		}
+		slog.Info("Next run scheduled", "time", next)
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+		ro := *o
+		ro.output = strings.ReplaceAll(o.output, scheduleTimePlaceholder, next.UTC().Format(basicTimeFormat))
+		since := next.Add(-o.window)
+		err := ro.process(ctx, func(p *processor) error {
+			return p.interval(ctx, since, next)
+		})
+		switch {
+		case ctx.Err() != nil:
+			return ctx.Err()
+		case err != nil && err != errThresholdExceeded:
+			slog.Warn("Scheduled run failed", "time", next, "error", err)
+		default:
+			slog.Info("Scheduled run completed", "time", next, "since", since)
+		}
+	}
+}
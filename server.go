@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alexeykiselev/waves-block-complexity/pkg/complexity"
+	"github.com/pkg/errors"
+	"github.com/wavesplatform/gowaves/pkg/proto"
+)
+
+const shutdownTimeout = 5 * time.Second
+
+// listen runs the HTTP server on the address until the context is canceled.
+func listen(ctx context.Context, addr string, h http.Handler) error {
+	srv := &http.Server{Addr: addr, Handler: h}
+	errs := make(chan error, 1)
+	go func() {
+		errs <- srv.ListenAndServe()
+	}()
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+	sctx, done := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer done()
+	if err := srv.Shutdown(sctx); err != nil {
+		return err
+	}
+	return ctx.Err()
+}
+
+// serve runs the HTTP API that calculates complexity of blocks on demand.
+// Endpoints are:
+//
+//	GET /block/{id}/complexity - complexity of the block with the given ID or of the last block if the ID is 'latest'
+//	GET /height/{height}/complexity - complexity of the block at the given height
+func (p *processor) serve(ctx context.Context, addr string) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/block/", p.handle(func(ctx context.Context, ref string) (*complexity.BlockComplexity, error) {
+		if ref != latestBlock {
+			if _, err := proto.NewBlockIDFromBase58(ref); err != nil {
+				return nil, badRequestError{err}
+			}
+		}
+		return p.blockByID(ctx, ref)
+	}))
+	mux.HandleFunc("/height/", p.handle(func(ctx context.Context, ref string) (*complexity.BlockComplexity, error) {
+		h, err := strconv.ParseUint(ref, 10, 64)
+		if err != nil {
+			return nil, badRequestError{err}
+		}
+		return p.an.BlockAt(ctx, h)
+	}))
+	slog.Info("Serving API", "address", addr)
+	if err := listen(ctx, addr, mux); err != nil && err != context.Canceled {
+		slog.Error("Failed to serve API", "error", err)
+		return err
+	}
+	return ctx.Err()
+}
+
+type badRequestError struct {
+	error
+}
+
+// handle returns the handler of paths like `/{prefix}/{ref}/complexity` that analyzes the block using the function.
+func (p *processor) handle(analyze func(ctx context.Context, ref string) (*complexity.BlockComplexity, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+		if len(parts) != 3 || parts[2] != "complexity" || parts[1] == "" {
+			writeError(w, http.StatusNotFound, "not found")
+			return
+		}
+		bc, err := analyze(r.Context(), parts[1])
+		if err != nil {
+			var (
+				status int
+				bre    badRequestError
+			)
+			switch {
+			case errors.As(err, &bre):
+				status = http.StatusBadRequest
+			case errors.Is(err, complexity.ErrBlockNotFound):
+				status = http.StatusNotFound
+			default:
+				status = http.StatusBadGateway
+			}
+			writeError(w, status, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, bc)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, struct {
+		Error string `json:"error"`
+	}{Error: msg})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Error("Failed to write response", "error", err)
+	}
+}
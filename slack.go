@@ -0,0 +1,19 @@
+package main
+
+import "net/http"
+
+// slackNotifier posts messages to the Slack incoming webhook.
+type slackNotifier struct {
+	webhook string
+	cl      *http.Client
+}
+
+func (n *slackNotifier) send(text string) error {
+	return postJSON(n.cl, n.webhook, struct {
+		Text string `json:"text"`
+	}{Text: text})
+}
+
+func (n *slackNotifier) name() string {
+	return "slack"
+}
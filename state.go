@@ -0,0 +1,55 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/alexeykiselev/waves-block-complexity/pkg/complexity"
+)
+
+const defaultStateFile = "waves-block-complexity.state"
+
+// rangeState is the progress of an interrupted range scan.
+type rangeState struct {
+	From        uint64                  `json:"from"`
+	To          uint64                  `json:"to"`
+	Accumulator *complexity.Accumulator `json:"accumulator"`
+
+	resumed bool // State is loaded from the file
+}
+
+func loadState(name string) (*rangeState, error) {
+	b, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	s := &rangeState{Accumulator: complexity.NewAccumulator(), resumed: true}
+	if err := json.Unmarshal(b, s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// save writes the state to a temporary file which replaces the file with the name, so the previous
+// state is not lost if writing fails.
+func (s *rangeState) save(name string) error {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(name), filepath.Base(name)+".*")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), name)
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"net"
+	"strconv"
+
+	"github.com/alexeykiselev/waves-block-complexity/pkg/complexity"
+)
+
+const (
+	statsdComplexityGauge  = "waves.block.complexity"
+	statsdUtilizationGauge = "waves.block.utilization"
+)
+
+// statsdPrinter passes results to the underlying printer and pushes the total complexity and utilization of every
+// processed block as StatsD gauges over UDP. Metrics are sent on the best effort basis, failures to send them are
+// logged and do not stop processing.
+type statsdPrinter struct {
+	printer
+	conn net.Conn
+}
+
+func newStatsdPrinter(out printer, addr string) (*statsdPrinter, error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return &statsdPrinter{printer: out, conn: conn}, nil
+}
+
+func (p *statsdPrinter) block(b complexity.BlockComplexity) error {
+	p.send(b)
+	return p.printer.block(b)
+}
+
+func (p *statsdPrinter) summary(b complexity.BlockComplexity) error {
+	p.send(b)
+	return p.printer.summary(b)
+}
+
+// send writes both gauges of the block in a single datagram.
+func (p *statsdPrinter) send(b complexity.BlockComplexity) {
+	msg := fmt.Sprintf("%s:%d|g\n%s:%s|g", statsdComplexityGauge, b.Complexity,
+		statsdUtilizationGauge, strconv.FormatFloat(b.Utilization, 'f', -1, 64))
+	if _, err := p.conn.Write([]byte(msg)); err != nil {
+		slog.Warn("Failed to send metrics to StatsD", "address", p.conn.RemoteAddr().String(), "error", err)
+	}
+}
+
+func (p *statsdPrinter) close() {
+	if err := p.conn.Close(); err != nil {
+		slog.Warn("Failed to close connection to StatsD", "error", err)
+	}
+}
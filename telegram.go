@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const telegramAPI = "https://api.telegram.org"
+
+// telegramNotifier sends messages to the Telegram chat on behalf of the bot.
+type telegramNotifier struct {
+	token string
+	chat  string // ID of the chat or username of the channel
+	cl    *http.Client
+}
+
+func (n *telegramNotifier) send(text string) error {
+	return postJSON(n.cl, fmt.Sprintf("%s/bot%s/sendMessage", telegramAPI, n.token), struct {
+		ChatID    string `json:"chat_id"`
+		Text      string `json:"text"`
+		ParseMode string `json:"parse_mode"`
+	}{ChatID: n.chat, Text: telegramEscaper.Replace(text), ParseMode: "MarkdownV2"})
+}
+
+// telegramEscaper escapes the characters reserved by MarkdownV2 of Telegram, except asterisks enclosing
+// the emphasized parts of messages, which are left to make them bold.
+var telegramEscaper = strings.NewReplacer(
+	`\`, `\\`, "_", `\_`, "[", `\[`, "]", `\]`, "(", `\(`, ")", `\)`, "~", `\~`, "`", "\\`", ">", `\>`,
+	"#", `\#`, "+", `\+`, "-", `\-`, "=", `\=`, "|", `\|`, "{", `\{`, "}", `\}`, ".", `\.`, "!", `\!`,
+)
+
+func (n *telegramNotifier) name() string {
+	return "telegram"
+}
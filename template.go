@@ -0,0 +1,67 @@
+package main
+
+import (
+	"io"
+	"text/template"
+
+	"github.com/alexeykiselev/waves-block-complexity/pkg/complexity"
+	"github.com/pkg/errors"
+)
+
+const (
+	blockResult   = "block"
+	summaryResult = "summary"
+	statsResult   = "stats"
+)
+
+// templateResult is the value the output template is executed with, once for every result.
+type templateResult struct {
+	// Kind is "block" for the detailed result of a single block, "summary" for each of several blocks,
+	// or "stats" for the aggregated statistics of a range of blocks.
+	Kind string
+	// Block is the complexity of the block, nil for statistics.
+	Block *complexity.BlockComplexity
+	// Stats is the statistics of the range of blocks, nil for blocks.
+	Stats *complexity.RangeStats
+}
+
+var templateFuncs = template.FuncMap{
+	"typeName": complexity.TransactionTypeName,
+}
+
+// templatePrinter writes the results formatted with the Go template.
+type templatePrinter struct {
+	w io.Writer
+	t *template.Template
+}
+
+func newTemplatePrinter(w io.Writer, text string) (*templatePrinter, error) {
+	if text == "" {
+		return nil, errors.New("empty template")
+	}
+	t, err := template.New("output").Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid template")
+	}
+	return &templatePrinter{w: w, t: t}, nil
+}
+
+func (p *templatePrinter) block(b complexity.BlockComplexity) error {
+	return p.t.Execute(p.w, templateResult{Kind: blockResult, Block: &b})
+}
+
+func (p *templatePrinter) summary(b complexity.BlockComplexity) error {
+	return p.t.Execute(p.w, templateResult{Kind: summaryResult, Block: &b})
+}
+
+func (p *templatePrinter) stats(s complexity.RangeStats) error {
+	return p.t.Execute(p.w, templateResult{Kind: statsResult, Stats: &s})
+}
+
+func (p *templatePrinter) flush() error {
+	return nil
+}
+
+func (p *templatePrinter) streaming() bool {
+	return true
+}
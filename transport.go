@@ -0,0 +1,283 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"log/slog"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/wavesplatform/gowaves/pkg/client"
+	"golang.org/x/time/rate"
+)
+
+const (
+	defaultRetries      = 3
+	defaultRetryBackoff = 500 * time.Millisecond
+)
+
+// newTransport returns the HTTP transport for requests to nodes. Unless the proxy URL is given,
+// the proxy is taken from HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables.
+// If TLS configuration is given, it's used for connections to nodes.
+func newTransport(proxy string, tc *tls.Config, connectTimeout time.Duration) (*http.Transport, error) {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.DialContext = (&net.Dialer{Timeout: connectTimeout, KeepAlive: 30 * time.Second}).DialContext
+	t.TLSHandshakeTimeout = connectTimeout
+	if tc != nil {
+		t.TLSClientConfig = tc
+	}
+	if proxy != "" {
+		u, err := url.Parse(proxy)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid proxy URL")
+		}
+		if u.Scheme == "" || u.Host == "" {
+			return nil, errors.Errorf("invalid proxy URL '%s'", redacted(proxy))
+		}
+		t.Proxy = http.ProxyURL(u)
+	}
+	return t, nil
+}
+
+// newTLSConfig returns TLS configuration with the client certificate and the certificate authority
+// loaded from the PEM files, verification of the node's certificate is disabled if insecure is set.
+// Nil is returned if no files are given and verification is enabled.
+func newTLSConfig(certFile, keyFile, caFile string, insecure bool) (*tls.Config, error) {
+	if certFile == "" && keyFile == "" && caFile == "" && !insecure {
+		return nil, nil
+	}
+	tc := &tls.Config{MinVersion: tls.VersionTLS12, InsecureSkipVerify: insecure}
+	if certFile != "" || keyFile != "" {
+		if certFile == "" || keyFile == "" {
+			return nil, errors.New("both client certificate and key are required")
+		}
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to load client certificate")
+		}
+		tc.Certificates = []tls.Certificate{cert}
+	}
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to load certificate authority")
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.Errorf("no certificates found in '%s'", caFile)
+		}
+		tc.RootCAs = pool
+	}
+	return tc, nil
+}
+
+// rateLimitingDoer delays requests to keep their rate within the limit of the token bucket.
+type rateLimitingDoer struct {
+	doer    client.Doer
+	limiter *rate.Limiter
+}
+
+// newRateLimitingDoer limits requests to the given number per second, allowing bursts of the same size.
+func newRateLimitingDoer(doer client.Doer, rps float64) *rateLimitingDoer {
+	burst := int(rps)
+	if burst < 1 {
+		burst = 1
+	}
+	return &rateLimitingDoer{doer: doer, limiter: rate.NewLimiter(rate.Limit(rps), burst)}
+}
+
+func (d *rateLimitingDoer) Do(req *http.Request) (*http.Response, error) {
+	if err := d.limiter.Wait(req.Context()); err != nil {
+		return nil, err
+	}
+	return d.doer.Do(req)
+}
+
+// retryingDoer repeats idempotent requests that failed with a transport error or a server side
+// status using exponential backoff with jitter.
+type retryingDoer struct {
+	doer    client.Doer
+	retries int
+	backoff time.Duration
+}
+
+func (d *retryingDoer) Do(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return d.doer.Do(req)
+	}
+	for attempt := 0; ; attempt++ {
+		resp, err := d.doer.Do(req)
+		if attempt >= d.retries || !retryable(resp, err) || req.Context().Err() != nil {
+			return resp, err
+		}
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			_ = resp.Body.Close()
+			err = errors.Errorf("unexpected status code %d", resp.StatusCode)
+		}
+		delay := d.delay(attempt)
+		slog.Warn("Request failed, retrying", "url", req.URL.Redacted(), "delay", delay, "error", err)
+		t := time.NewTimer(delay)
+		select {
+		case <-req.Context().Done():
+			t.Stop()
+			return nil, req.Context().Err()
+		case <-t.C:
+		}
+	}
+}
+
+// delay returns the exponentially growing backoff for the attempt, randomized in the range [d/2, d].
+func (d *retryingDoer) delay(attempt int) time.Duration {
+	b := d.backoff << attempt
+	if b <= 0 {
+		return 0
+	}
+	half := int64(b / 2)
+	return time.Duration(half + rand.Int63n(half+1))
+}
+
+func retryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError
+}
+
+// failoverDoer sends requests to the current node and switches to the next one from the list if the request
+// fails. Requests must be built against the first node in the list. Once switched, the following requests are
+// sent to the new node.
+type failoverDoer struct {
+	doer  client.Doer
+	nodes []string
+
+	mu      sync.Mutex
+	current int
+}
+
+func (d *failoverDoer) Do(req *http.Request) (*http.Response, error) {
+	d.mu.Lock()
+	start := d.current
+	d.mu.Unlock()
+	var (
+		resp *http.Response
+		err  error
+	)
+	for i := 0; i < len(d.nodes); i++ {
+		n := (start + i) % len(d.nodes)
+		r, rerr := d.rebase(req, d.nodes[n])
+		if rerr != nil {
+			return nil, rerr
+		}
+		resp, err = d.doer.Do(r)
+		if !retryable(resp, err) || req.Context().Err() != nil {
+			d.mu.Lock()
+			d.current = n
+			d.mu.Unlock()
+			return resp, err
+		}
+		if i < len(d.nodes)-1 {
+			if resp != nil {
+				_, _ = io.Copy(io.Discard, resp.Body)
+				_ = resp.Body.Close()
+			}
+			slog.Warn("Request to node failed, switching to next node", "node", redacted(d.nodes[n]), "next", redacted(d.nodes[(n+1)%len(d.nodes)]))
+		}
+	}
+	return resp, err
+}
+
+// rebase returns a copy of the request with the URL of the first node replaced by the given node's URL.
+func (d *failoverDoer) rebase(req *http.Request, node string) (*http.Request, error) {
+	if node == d.nodes[0] {
+		return req, nil
+	}
+	u, err := url.Parse(node + strings.TrimPrefix(req.URL.String(), d.nodes[0]))
+	if err != nil {
+		return nil, err
+	}
+	r := req.Clone(req.Context())
+	r.URL = u
+	r.Host = u.Host
+	return r, nil
+}
+
+// apiKeyDoer attaches the node's API key to every request.
+type apiKeyDoer struct {
+	doer client.Doer
+	key  string
+}
+
+func (d *apiKeyDoer) Do(req *http.Request) (*http.Response, error) {
+	r := req.Clone(req.Context())
+	r.Header.Set("X-API-Key", d.key)
+	return d.doer.Do(r)
+}
+
+// basicAuthDoer sends the credentials using HTTP Basic authentication with every request.
+// Credentials given in a node URL are sent by the HTTP client itself, unless overridden by this doer.
+type basicAuthDoer struct {
+	doer     client.Doer
+	user     string
+	password string
+}
+
+func (d *basicAuthDoer) Do(req *http.Request) (*http.Response, error) {
+	r := req.Clone(req.Context())
+	r.SetBasicAuth(d.user, d.password)
+	return d.doer.Do(r)
+}
+
+// redacted returns the node URL with the password replaced, so it can be logged.
+func redacted(node string) string {
+	u, err := url.Parse(node)
+	if err != nil {
+		return node
+	}
+	return u.Redacted()
+}
+
+// loggingDoer logs every request with the response status and the time it took at debug level,
+// headers of requests and responses are logged as well on verbosity levels above one.
+type loggingDoer struct {
+	doer  client.Doer
+	level int
+}
+
+func (d *loggingDoer) Do(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	if d.level > 1 {
+		slog.Debug("Request", "method", req.Method, "url", req.URL.Redacted(), "headers", headers(req.Header))
+	}
+	resp, err := d.doer.Do(req)
+	if err != nil {
+		slog.Debug("Request failed", "method", req.Method, "url", req.URL.Redacted(), "duration", time.Since(start), "error", err)
+		return resp, err
+	}
+	slog.Debug("Request completed", "method", req.Method, "url", req.URL.Redacted(), "status", resp.StatusCode, "duration", time.Since(start))
+	if d.level > 1 {
+		slog.Debug("Response", "status", resp.StatusCode, "headers", headers(resp.Header))
+	}
+	return resp, nil
+}
+
+// headers returns the HTTP headers for logging with values of credentials hidden.
+func headers(h http.Header) map[string]string {
+	r := make(map[string]string, len(h))
+	for n, v := range h {
+		if n == "Authorization" || n == "X-Api-Key" {
+			r[n] = "xxxxx"
+			continue
+		}
+		r[n] = strings.Join(v, ", ")
+	}
+	return r
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"log/slog"
+
+	"github.com/alexeykiselev/waves-block-complexity/pkg/complexity"
+	"github.com/pkg/errors"
+)
+
+// unconfirmed reports the estimated complexity of transactions in the node's UTX pool.
+func (p *processor) unconfirmed(ctx context.Context, w io.Writer, format string, byDApp bool) error {
+	u, err := p.an.Unconfirmed(ctx)
+	if err != nil {
+		slog.Error("Failed to estimate unconfirmed transactions", "error", err)
+		return err
+	}
+	switch format {
+	case textFormat:
+		printUnconfirmed(log.New(w, "", 0), u, byDApp)
+		return nil
+	case jsonFormat:
+		if !byDApp {
+			u.DApps = nil
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(u)
+	default:
+		err := errors.Errorf("output format '%s' is not supported for unconfirmed transactions", format)
+		slog.Error("Invalid parameters", "error", err)
+		return err
+	}
+}
+
+func printUnconfirmed(l *log.Logger, u *complexity.UnconfirmedComplexity, byDApp bool) {
+	for _, c := range u.Transactions {
+		if c.Invocation != nil {
+			l.Printf("[%s]\t%d\t%s", c.ID.String(), c.SpentComplexity, invocation(*c.Invocation))
+		}
+	}
+	l.Println()
+	l.Printf("Height: %d", u.Height)
+	l.Printf("Unconfirmed Transactions: %d", len(u.Transactions))
+	l.Printf("Estimated Complexity: %d", u.Complexity)
+	if u.VerifierComplexity > 0 {
+		l.Printf("Verifiers Complexity: %d", u.VerifierComplexity)
+	}
+	if u.AssetsComplexity > 0 {
+		l.Printf("Smart Assets Complexity: %d", u.AssetsComplexity)
+	}
+	if u.Limit > 0 {
+		l.Printf("Next Block Complexity Limit: %d", u.Limit)
+		l.Printf("Utilization of Next Block: %.2f%%", u.Utilization)
+		l.Printf("Blocks to Mine: %d", (u.Complexity+u.Limit-1)/u.Limit)
+	}
+	if byDApp && len(u.DApps) > 0 {
+		l.Println()
+		l.Printf("Complexity by dApp:")
+		for _, d := range u.DApps {
+			l.Printf("%s\t%d\t%d", d.DApp, d.Transactions, d.Complexity)
+		}
+	}
+}
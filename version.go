@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"runtime"
+	"runtime/debug"
+)
+
+const gowavesModule = "github.com/wavesplatform/gowaves"
+
+// Build metadata, set at build time with
+// -ldflags "-X main.version=v1.0.0 -X main.commit=$(git rev-parse HEAD) -X main.date=$(date -u +%FT%TZ)".
+// If not set, the module version, the commit and its date are taken from the information embedded by Go toolchain.
+var (
+	version = "dev"
+	commit  = ""
+	date    = ""
+)
+
+func init() {
+	commands = append(commands, command{
+		name:        "version",
+		description: "Print version and build information",
+		offline:     true,
+		flags:       func(fs *flag.FlagSet, o *options) {},
+		run: func(_ context.Context, _ *options, args []string) error {
+			if err := noArguments(args); err != nil {
+				return err
+			}
+			fmt.Println(versionInfo())
+			return nil
+		},
+	})
+}
+
+// versionInfo returns the version of the program, its build metadata and the version of gowaves library.
+func versionInfo() string {
+	v, c, d, lib := version, commit, date, "unknown"
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		if v == "dev" && bi.Main.Version != "" && bi.Main.Version != "(devel)" {
+			v = bi.Main.Version
+		}
+		for _, s := range bi.Settings {
+			switch {
+			case s.Key == "vcs.revision" && c == "":
+				c = s.Value
+			case s.Key == "vcs.time" && d == "":
+				d = s.Value
+			}
+		}
+		for _, m := range bi.Deps {
+			if m.Path == gowavesModule {
+				lib = m.Version
+				if m.Replace != nil {
+					lib = m.Replace.Version
+				}
+			}
+		}
+	}
+	if c == "" {
+		c = "unknown"
+	}
+	if d == "" {
+		d = "unknown"
+	}
+	return fmt.Sprintf("%s %s (commit %s, date %s, gowaves %s, %s %s/%s)",
+		programName, v, c, d, lib, runtime.Version(), runtime.GOOS, runtime.GOARCH)
+}
@@ -1,132 +1,702 @@
 package main
 
 import (
+	"bufio"
 	"context"
-	"flag"
-	"fmt"
-	"log"
+	"io"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"os"
-	"os/signal"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/alexeykiselev/waves-block-complexity/pkg/complexity"
 	"github.com/pkg/errors"
 	"github.com/wavesplatform/gowaves/pkg/client"
 	"github.com/wavesplatform/gowaves/pkg/crypto"
 	"github.com/wavesplatform/gowaves/pkg/proto"
+	"go.uber.org/zap"
 )
 
 const (
 	defaultNetworkTimeout = 15 * time.Second
+	defaultConnectTimeout = 10 * time.Second
+	defaultLiquidPoll     = time.Second
+	defaultPollInterval   = 10 * time.Second
+	defaultConcurrency    = 8
 	defaultScheme         = "http"
+	latestBlock           = "latest"
 )
 
-type Complexity struct {
-	ID              crypto.Digest `json:"id"`
-	SpentComplexity int           `json:"spentComplexity"`
-}
+var (
+	errThresholdExceeded = errors.New("block complexity threshold exceeded")
+	errDiscrepancy       = errors.New("block complexity differs between nodes")
+)
 
 func main() {
 	if err := run(); err != nil {
 		switch err {
 		case context.Canceled:
 			os.Exit(130)
+		case errThresholdExceeded:
+			os.Exit(2)
+		case errDiscrepancy:
+			os.Exit(3)
 		default:
 			os.Exit(1)
 		}
 	}
 }
 
-func run() error {
-	var (
-		node    string
-		block   string
-		timeout time.Duration
-	)
+// processor creates the processor writing results to the printer and reporting progress unless it's nil,
+// the returned function releases its resources.
+func (o *options) processor(ctx context.Context, out printer, pg *progress) (*processor, func(), error) {
+	if o.nodeState != "" {
+		return o.stateProcessor(out, pg)
+	}
+	var nodes []string
+	for _, s := range strings.Split(o.node, ",") {
+		n, err := validateNodeURL(strings.TrimSpace(s))
+		if err != nil {
+			slog.Error("Invalid node URL", "url", redacted(s), "error", err)
+			return nil, nil, err
+		}
+		nodes = append(nodes, n)
+	}
+	if o.insecure {
+		slog.Warn("Verification of node's TLS certificate is disabled")
+	}
+	tc, err := newTLSConfig(o.tlsCert, o.tlsKey, o.tlsCA, o.insecure)
+	if err != nil {
+		slog.Error("Invalid TLS parameters", "error", err)
+		return nil, nil, err
+	}
+	tr, err := newTransport(o.proxy, tc, o.connectTimeout)
+	if err != nil {
+		slog.Error("Invalid network parameters", "error", err)
+		return nil, nil, err
+	}
+	var doer client.Doer = &http.Client{Transport: tr, Timeout: o.requestTimeout}
+	if o.otlpEndpoint != "" {
+		doer = &tracingDoer{doer: doer}
+	}
+	if o.rps < 0 {
+		err := errors.Errorf("invalid number of requests per second %g", o.rps)
+		slog.Error("Invalid network parameters", "error", err)
+		return nil, nil, err
+	}
+	if o.rps > 0 {
+		doer = newRateLimitingDoer(doer, o.rps)
+	}
+	if o.verbosity > 0 {
+		doer = &loggingDoer{doer: doer, level: o.verbosity}
+	}
+	doer = &retryingDoer{doer: doer, retries: o.retries, backoff: o.retryBackoff}
+	if len(nodes) > 1 {
+		doer = &failoverDoer{doer: doer, nodes: nodes}
+	}
+	if o.user != "" {
+		doer = &basicAuthDoer{doer: doer, user: o.user, password: o.password}
+	}
+	if o.apiKey != "" {
+		doer = &apiKeyDoer{doer: doer, key: o.apiKey}
+	}
+	opts, err := o.analyzerOptions(pg)
+	if err != nil {
+		return nil, nil, err
+	}
+	var src complexity.Source
+	closer := func() {}
+	if o.grpcAddr != "" {
+		gs, err := complexity.NewGRPCSource(o.grpcAddr, o.connectTimeout, o.requestTimeout)
+		if err != nil {
+			slog.Error("Failed to connect to gRPC API", "address", o.grpcAddr, "error", err)
+			return nil, nil, err
+		}
+		src = gs
+		closer = gs.Close
+	}
+	if o.cache != "" {
+		c, err := newDiskCache(o.cache)
+		if err != nil {
+			closer()
+			slog.Error("Failed to create cache", "dir", o.cache, "error", err)
+			return nil, nil, err
+		}
+		opts.Cache = c
+	} else if o.memoryCache {
+		opts.Cache = newMemoryCache()
+	}
+	an := complexity.NewAnalyzer(newClient(nodes[0], o.apiKey, doer), src, opts)
+	if err := an.CheckNetwork(ctx); err != nil {
+		closer()
+		slog.Error("Invalid scheme", "scheme", o.scheme, "error", err)
+		return nil, nil, err
+	}
+	if !o.skipPreflight {
+		ns, err := an.Preflight(ctx)
+		if err != nil {
+			closer()
+			if errors.Is(err, complexity.ErrNoTransactionsInfo) {
+				slog.Error("Node can't report complexity of transactions, use -estimate to estimate it locally", "error", err)
+			} else {
+				slog.Error("Node is not ready for the analysis, the check can be skipped with -skip-preflight", "error", err)
+			}
+			return nil, nil, err
+		}
+		slog.Debug("Node is ready", "version", ns.Version, "height", ns.Height, "age", ns.Age.Round(time.Second))
+	}
+	return &processor{an: an, out: out, progress: pg}, closer, nil
+}
 
-	flag.StringVar(&node, "node", "nodes.wavesnodes.com", "Waves node API URL, default value is nodes.wavesnodes.com")
-	flag.StringVar(&block, "block", "", "Block ID, no default value")
-	flag.DurationVar(&timeout, "timeout", defaultNetworkTimeout, "Network timeout, seconds. Default value is 15")
-	flag.Parse()
+// stateProcessor creates the processor of blocks of the local node's state, the returned function closes the state.
+func (o *options) stateProcessor(out printer, pg *progress) (*processor, func(), error) {
+	opts, err := o.analyzerOptions(pg)
+	if err != nil {
+		return nil, nil, err
+	}
+	if opts.Scheme == 0 {
+		opts.Scheme = proto.MainNetScheme
+	}
+	if !o.stateWritable {
+		err := errors.New("state of the node is opened for writing and may be modified, give a copy of the state with -node-state-writable")
+		slog.Error("Invalid parameters", "dir", o.nodeState, "error", err)
+		return nil, nil, err
+	}
+	// The node's library logs debug messages to stderr with the global logger of zap unless it's replaced
+	zap.ReplaceGlobals(zap.NewNop())
+	ss, err := complexity.OpenState(o.nodeState, opts.Scheme)
+	if err != nil {
+		slog.Error("Failed to open node's state", "dir", o.nodeState, "error", err)
+		return nil, nil, err
+	}
+	closer := func() {
+		if err := ss.Close(); err != nil {
+			slog.Error("Failed to close node's state", "dir", o.nodeState, "error", err)
+		}
+	}
+	an, err := complexity.NewStateAnalyzer(ss, opts)
+	if err != nil {
+		closer()
+		slog.Error("Failed to read node's state", "dir", o.nodeState, "error", err)
+		return nil, nil, err
+	}
+	return &processor{an: an, out: out, progress: pg}, closer, nil
+}
 
-	ctx, done := signal.NotifyContext(context.Background(), os.Interrupt)
-	defer done()
+// analyzerOptions validates the parameters of the analysis, the progress of transactions is reported unless
+// it's nil.
+func (o *options) analyzerOptions(pg *progress) (complexity.Options, error) {
+	sch, err := parseScheme(o.scheme)
+	if err != nil {
+		slog.Error("Invalid scheme", "scheme", o.scheme, "error", err)
+		return complexity.Options{}, err
+	}
+	var types []proto.TransactionType
+	if o.types != "" {
+		for _, n := range strings.Split(o.types, ",") {
+			t, err := complexity.ParseTransactionType(strings.TrimSpace(n))
+			if err != nil {
+				slog.Error("Invalid transaction types", "types", o.types, "error", err)
+				return complexity.Options{}, err
+			}
+			types = append(types, t)
+		}
+	}
+	if o.strict && o.lenient {
+		err := errors.New("flags -strict and -lenient can not be used together")
+		slog.Error("Invalid parameters", "error", err)
+		return complexity.Options{}, err
+	}
+	if o.generator != "" {
+		if _, err := proto.NewAddressFromString(o.generator); err != nil {
+			slog.Error("Invalid generator address", "address", o.generator, "error", err)
+			return complexity.Options{}, err
+		}
+	}
+	opts := complexity.Options{
+		Concurrency:      o.concurrency,
+		BlockConcurrency: o.blockConcurrency,
+		Scheme:           sch,
+		Types:            types,
+		Addresses:        o.addresses,
+		Generator:        o.generator,
+		Estimate:         o.estimate,
+		Strict:           o.strict,
+		Lenient:          o.lenient,
+		Verify:           o.verify,
+		ScriptVersions:   o.scriptVersions,
+		CallComplexities: o.callComplexities,
+		Verifiers:        o.verifiers,
+		Assets:           o.assets,
+	}
+	if pg != nil {
+		opts.Progress = pg.transactions
+	}
+	return opts, nil
+}
+
+// subscribe connects to the node's Blockchain Updates API and reports new blocks as the processor receives them.
+func (o *options) subscribe(ctx context.Context, p *processor, liquid bool) error {
+	us, err := complexity.NewUpdatesSource(o.updates, o.connectTimeout)
+	if err != nil {
+		slog.Error("Failed to connect to Blockchain Updates API", "address", o.updates, "error", err)
+		return err
+	}
+	defer us.Close()
+	return p.subscribe(ctx, us, liquid, o.poll)
+}
+
+// processor analyzes blocks and reports their complexities to the printer.
+type processor struct {
+	an       *complexity.Analyzer
+	out      printer
+	progress *progress
+
+	threshold  int                  // Complexity of a block that is considered excessive, no threshold if zero
+	exceeded   bool                 // At least one block exceeded the threshold
+	state      string               // File to save the progress of an interrupted range scan to, not saved if empty
+	compare    *complexity.Analyzer // Analyzer of another node to compare the results with, not compared if nil
+	discrepant bool                 // Results of at least one block differ between the nodes
+}
+
+// block reports the detailed complexity of the block with the given ID.
+func (p *processor) block(ctx context.Context, id string) error {
+	bc, err := p.blockByID(ctx, id)
+	if err != nil {
+		slog.Error("Failed to analyze block", "id", id, "error", err)
+		return err
+	}
+	if err := p.compareBlock(ctx, *bc); err != nil {
+		return err
+	}
+	return p.detailed(*bc)
+}
 
-	n, err := validateNodeURL(node)
+// reference reports the detailed complexity of the block at the height if the reference is a number,
+// or of the block with the ID otherwise.
+func (p *processor) reference(ctx context.Context, ref string) error {
+	if h, err := strconv.ParseUint(ref, 10, 64); err == nil {
+		return p.blockAt(ctx, h)
+	}
+	return p.block(ctx, ref)
+}
+
+// transaction reports the detailed complexity of the block containing the transaction with the given ID.
+func (p *processor) transaction(ctx context.Context, id string) error {
+	txID, err := crypto.NewDigestFromBase58(id)
+	if err != nil {
+		slog.Error("Invalid transaction ID", "id", id, "error", err)
+		return err
+	}
+	h, err := p.an.TransactionHeight(ctx, txID)
 	if err != nil {
-		log.Printf("Invalid node URL '%s': %v", node, err)
+		slog.Error("Failed to locate transaction", "id", id, "error", err)
 		return err
 	}
-	cl := newClient(n, timeout)
-	b, err := getBlock(ctx, cl, block)
+	bc, err := p.an.BlockAt(ctx, h)
 	if err != nil {
-		log.Printf("Failed to get block with ID '%s': %v", block, err)
+		slog.Error("Failed to analyze block", "height", h, "error", err)
+		return err
+	}
+	found := false
+	for _, c := range bc.Transactions {
+		if c.ID == txID {
+			slog.Info("Transaction located", "id", id, "height", h, "block", bc.ID.String(), "complexity", c.SpentComplexity)
+			found = true
+			break
+		}
+	}
+	if !found {
+		slog.Warn("Transaction is excluded from the analysis of the block", "id", id, "height", h, "block", bc.ID.String())
+	}
+	if err := p.compareBlock(ctx, *bc); err != nil {
 		return err
 	}
-	scheme := b.Generator.Bytes()[1]
-	complexities, err := getTransactionsComplexities(ctx, cl, *b, scheme)
+	return p.detailed(*bc)
+}
+
+// blockAt reports the detailed complexity of the block at the given height.
+func (p *processor) blockAt(ctx context.Context, height uint64) error {
+	bc, err := p.an.BlockAt(ctx, height)
 	if err != nil {
-		log.Printf("Failed to get transactions complexities: %v", err)
+		slog.Error("Failed to analyze block", "height", height, "error", err)
+		return err
+	}
+	if err := p.compareBlock(ctx, *bc); err != nil {
 		return err
 	}
-	total := 0
-	for _, c := range complexities {
-		total += c.SpentComplexity
-		if c.SpentComplexity > 0 {
-			log.Printf("[%s]\t%d", c.ID.String(), c.SpentComplexity)
+	return p.detailed(*bc)
+}
+
+// blocks reports the summary of each block given by ID or height.
+func (p *processor) blocks(ctx context.Context, refs []string) error {
+	for _, ref := range refs {
+		bc, err := p.blockByReference(ctx, ref)
+		if err != nil {
+			slog.Error("Failed to analyze block", "block", ref, "error", err)
+			return err
+		}
+		if err := p.compareBlock(ctx, *bc); err != nil {
+			return err
+		}
+		if err := p.summary(*bc); err != nil {
+			return err
 		}
 	}
-	log.Println()
-	log.Printf("Block Complexity: %d", total)
 	return nil
 }
 
-func getBlock(ctx context.Context, client *client.Client, id string) (*client.Block, error) {
-	blockID, err := proto.NewBlockIDFromBase58(id)
+// blocksFile reads block IDs or heights line by line from the file (or stdin if the name is "-")
+// and reports the summary of each block as soon as it is processed.
+func (p *processor) blocksFile(ctx context.Context, name string) error {
+	var r io.Reader = os.Stdin
+	if name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			slog.Error("Failed to open blocks file", "error", err)
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		ref := strings.TrimSpace(s.Text())
+		if ref == "" {
+			continue
+		}
+		bc, err := p.blockByReference(ctx, ref)
+		if err != nil {
+			slog.Error("Failed to analyze block", "block", ref, "error", err)
+			return err
+		}
+		if err := p.compareBlock(ctx, *bc); err != nil {
+			return err
+		}
+		if err := p.summary(*bc); err != nil {
+			return err
+		}
+	}
+	if err := s.Err(); err != nil {
+		slog.Error("Failed to read blocks file", "error", err)
+		return err
+	}
+	return nil
+}
+
+func (p *processor) heightRange(ctx context.Context, from, to uint64) error {
+	return p.scan(ctx, &rangeState{From: from, To: to, Accumulator: complexity.NewAccumulator()})
+}
+
+// scan continues the range scan from the block next to the last processed one. If the scan fails,
+// its progress is saved to the state file, which is removed after the resumed scan completes.
+func (p *processor) scan(ctx context.Context, s *rangeState) error {
+	from := s.From
+	if s.Accumulator.Last >= from {
+		from = s.Accumulator.Last + 1
+	}
+	if from <= s.To {
+		err := p.an.Accumulate(ctx, s.Accumulator, from, s.To, func(bc complexity.BlockComplexity) error {
+			p.progress.block(s.To - from + 1)
+			return p.summary(bc)
+		})
+		if err != nil {
+			slog.Error("Failed to analyze blocks range", "error", err)
+			if p.state != "" && s.Accumulator.Last >= s.From {
+				if serr := s.save(p.state); serr != nil {
+					slog.Error("Failed to save progress", "file", p.state, "error", serr)
+				} else {
+					slog.Info("Progress saved, use -resume flag to continue", "file", p.state, "height", s.Accumulator.Last)
+				}
+			}
+			return err
+		}
+	}
+	if s.resumed {
+		if err := os.Remove(p.state); err != nil && !os.IsNotExist(err) {
+			slog.Warn("Failed to remove state file", "file", p.state, "error", err)
+		}
+	}
+	return p.out.stats(s.Accumulator.Stats())
+}
+
+// last reports the summaries and statistics of the given number of blocks ending at the current height.
+func (p *processor) last(ctx context.Context, n uint64) error {
+	h, err := p.an.Height(ctx)
 	if err != nil {
-		return nil, err
+		slog.Error("Failed to get blockchain height", "error", err)
+		return err
 	}
-	block, _, err := client.Blocks.Signature(ctx, blockID)
+	from := uint64(1)
+	if h > n {
+		from = h - n + 1
+	}
+	return p.heightRange(ctx, from, h)
+}
+
+// interval reports the summaries and statistics of the blocks created within the time interval,
+// the interval is open-ended if the corresponding time is zero.
+func (p *processor) interval(ctx context.Context, since, until time.Time) error {
+	h, err := p.an.Height(ctx)
 	if err != nil {
-		return nil, err
+		slog.Error("Failed to get blockchain height", "error", err)
+		return err
+	}
+	from, to := uint64(1), h
+	if !since.IsZero() {
+		if from, err = p.an.SearchHeight(ctx, since, h); err != nil {
+			slog.Error("Failed to find block by time", "time", since, "error", err)
+			return err
+		}
+	}
+	if !until.IsZero() {
+		n, err := p.an.SearchHeight(ctx, until, h)
+		if err != nil {
+			slog.Error("Failed to find block by time", "time", until, "error", err)
+			return err
+		}
+		to = n - 1
+	}
+	if from > to {
+		err := errors.New("no blocks created within the interval")
+		slog.Error("Invalid parameters", "error", err)
+		return err
 	}
-	return block, nil
+	slog.Debug("Blocks found within the interval", "from", from, "to", to)
+	return p.heightRange(ctx, from, to)
 }
 
-func getTransactionsComplexities(ctx context.Context, cl *client.Client, block client.Block, scheme byte) ([]Complexity, error) {
-	r := make([]Complexity, 0, block.TransactionCount)
-	for _, tx := range block.Transactions {
-		d, err := tx.GetID(scheme)
+// follow polls the node for the blockchain height and reports the summary of every new block.
+// A block is processed only after the next block appears, so its set of transactions is final.
+// Processing starts from the last finalized block, or from the given number of blocks preceding it.
+func (p *processor) follow(ctx context.Context, poll time.Duration, history uint64) error {
+	var last uint64
+	ticker := time.NewTicker(poll)
+	defer ticker.Stop()
+	for {
+		h, err := p.an.Height(ctx)
 		if err != nil {
-			return nil, err
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			slog.Error("Failed to get blockchain height", "error", err)
+		} else {
+			if last == 0 && h > 1 {
+				last = h - 2 - min(history, h-2)
+			}
+			for ; last+1 < h; last++ {
+				bc, err := p.an.BlockAt(ctx, last+1)
+				if err != nil {
+					slog.Error("Failed to analyze block", "height", last+1, "error", err)
+					return err
+				}
+				if err := p.summary(*bc); err != nil {
+					return err
+				}
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
 		}
-		id, err := crypto.NewDigestFromBytes(d)
+	}
+}
+
+// liquid polls the node for the last block, which is the liquid one growing with microblocks, and reports
+// its summary every time new transactions are added to the block or a new block appears.
+func (p *processor) liquid(ctx context.Context, poll time.Duration) error {
+	var last proto.BlockID
+	ticker := time.NewTicker(poll)
+	defer ticker.Stop()
+	for {
+		bc, err := p.an.LastBlock(ctx)
 		if err != nil {
-			return nil, err
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			slog.Error("Failed to analyze last block", "error", err)
+		} else if bc.ID != last {
+			last = bc.ID
+			if err := p.summary(*bc); err != nil {
+				return err
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
 		}
-		c, err := getComplexity(ctx, cl, id)
+	}
+}
+
+// subscribe reports the summaries of blocks as the node's Blockchain Updates stream delivers them instead of polling.
+// In liquid mode the summary of the liquid block is reported every time a block or a microblock is appended to it,
+// otherwise a block is reported once the next block is appended, starting from the last finalized block.
+// The stream is resubscribed after the poll interval if it fails.
+func (p *processor) subscribe(ctx context.Context, us *complexity.UpdatesSource, liquid bool, poll time.Duration) error {
+	var last uint64 // Height of the last reported block in follow mode
+	for {
+		h, err := p.an.Height(ctx)
 		if err != nil {
-			return nil, err
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			slog.Error("Failed to get blockchain height", "error", err)
+		} else {
+			from := h
+			if !liquid {
+				if last == 0 && h > 1 {
+					last = h - 2
+				}
+				from = last + 1
+			}
+			var failed error
+			err := us.Subscribe(ctx, from, func(u complexity.Update) error {
+				failed = p.update(ctx, u, liquid, &last)
+				return failed
+			})
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if failed != nil {
+				return failed
+			}
+			slog.Error("Failed to receive blockchain updates", "height", from, "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(poll):
 		}
-		r = append(r, *c)
 	}
-	return r, nil
 }
 
-func getComplexity(ctx context.Context, cl *client.Client, id crypto.Digest) (*Complexity, error) {
-	req, err := http.NewRequest("GET",
-		fmt.Sprintf("%s/transactions/info/%s", cl.GetOptions().BaseUrl, id.String()), nil)
+// update reports the block changed by the blockchain update, logging rollbacks. In follow mode the height of the
+// last reported block is advanced, or moved back by a rollback, so the blocks of the new fork are reported again.
+// Blocks missing in the stream after a rollback are requested from the node.
+func (p *processor) update(ctx context.Context, u complexity.Update, liquid bool, last *uint64) error {
+	switch u.Type {
+	case complexity.BlockRolledBack:
+		slog.Warn("Blockchain rolled back", "height", u.Height, "block", u.ID.String(),
+			"blocks", u.RemovedBlocks, "transactions", u.RemovedTransactions)
+	case complexity.MicroBlockRolledBack:
+		slog.Warn("Liquid block rolled back", "height", u.Height, "block", u.ID.String(),
+			"transactions", u.RemovedTransactions)
+	}
+	var bc *complexity.BlockComplexity
+	var err error
+	switch {
+	case liquid && u.Block != nil:
+		bc, err = p.an.Analyze(ctx, u.Block)
+	case liquid:
+		bc, err = p.an.LastBlock(ctx)
+	case u.Type == complexity.BlockRolledBack || u.Type == complexity.MicroBlockRolledBack:
+		if u.Height <= *last {
+			*last = u.Height - 1
+		}
+		return nil
+	case u.Type != complexity.BlockAppended || u.Height <= *last+1:
+		return nil
+	case u.Final != nil:
+		*last = u.Height - 1
+		bc, err = p.an.Analyze(ctx, u.Final)
+	default:
+		*last = u.Height - 1
+		bc, err = p.an.BlockAt(ctx, u.Height-1)
+	}
 	if err != nil {
-		return nil, err
+		slog.Error("Failed to analyze block", "height", u.Height, "error", err)
+		return err
+	}
+	return p.summary(*bc)
+}
+
+// detailed reports the detailed complexity of the block.
+func (p *processor) detailed(bc complexity.BlockComplexity) error {
+	p.check(bc)
+	if err := p.out.block(bc); err != nil {
+		slog.Error("Failed to write output", "error", err)
+		return err
+	}
+	return nil
+}
+
+// summary reports a summary of the block: height, ID, number of transactions and total complexity.
+func (p *processor) summary(bc complexity.BlockComplexity) error {
+	p.check(bc)
+	if err := p.out.summary(bc); err != nil {
+		slog.Error("Failed to write output", "error", err)
+		return err
+	}
+	return nil
+}
+
+// compareBlock analyzes the same block on the compared node and logs the transactions which results differ,
+// remembering the discrepancy. A block missing on the compared node is a discrepancy too.
+func (p *processor) compareBlock(ctx context.Context, bc complexity.BlockComplexity) error {
+	if p.compare == nil {
+		return nil
+	}
+	other, err := p.compare.BlockByID(ctx, bc.ID)
+	if errors.Is(err, complexity.ErrBlockNotFound) {
+		slog.Warn("Block not found on compared node", "id", bc.ID.String(), "height", bc.Height, "error", err)
+		p.discrepant = true
+		return nil
 	}
-	res := new(Complexity)
-	_, err = cl.Do(ctx, req, res)
+	if err != nil {
+		slog.Error("Failed to analyze block on compared node", "id", bc.ID.String(), "error", err)
+		return err
+	}
+	ds := complexity.Compare(bc, *other)
+	for _, d := range ds {
+		switch {
+		case d.Compared == nil:
+			slog.Warn("Transaction missing on compared node", "id", d.ID.String(), "height", bc.Height)
+		case d.Local == nil:
+			slog.Warn("Transaction missing on node", "id", d.ID.String(), "height", bc.Height)
+		default:
+			slog.Warn("Transaction complexity differs between nodes", "id", d.ID.String(), "height", bc.Height,
+				"complexity", d.Local.SpentComplexity, "compared", d.Compared.SpentComplexity,
+				"status", d.Local.ApplicationStatus, "comparedStatus", d.Compared.ApplicationStatus)
+		}
+	}
+	if len(ds) > 0 || other.Complexity != bc.Complexity {
+		slog.Warn("Block complexity differs between nodes", "id", bc.ID.String(), "height", bc.Height,
+			"complexity", bc.Complexity, "compared", other.Complexity, "transactions", len(ds))
+		p.discrepant = true
+	}
+	return nil
+}
+
+// check remembers if the block's complexity exceeds the threshold.
+func (p *processor) check(bc complexity.BlockComplexity) {
+	if p.threshold > 0 && bc.Complexity > p.threshold {
+		slog.Warn("Block complexity exceeds threshold", "id", bc.ID.String(), "height", bc.Height, "complexity", bc.Complexity, "threshold", p.threshold)
+		p.exceeded = true
+	}
+}
+
+func (p *processor) blockByID(ctx context.Context, id string) (*complexity.BlockComplexity, error) {
+	if id == latestBlock {
+		return p.an.LastBlock(ctx)
+	}
+	blockID, err := proto.NewBlockIDFromBase58(id)
 	if err != nil {
 		return nil, err
 	}
-	return res, nil
+	return p.an.BlockByID(ctx, blockID)
+}
+
+// blockByReference analyzes the block at the height if the reference is a number, or the block with the ID otherwise.
+func (p *processor) blockByReference(ctx context.Context, ref string) (*complexity.BlockComplexity, error) {
+	if h, err := strconv.ParseUint(ref, 10, 64); err == nil {
+		return p.an.BlockAt(ctx, h)
+	}
+	return p.blockByID(ctx, ref)
 }
 
 func validateNodeURL(s string) (string, error) {
@@ -149,10 +719,27 @@ func validateNodeURL(s string) (string, error) {
 	return u.String(), nil
 }
 
-func newClient(url string, timeout time.Duration) *client.Client {
+// parseScheme returns the chain ID given as a single character or as a byte value, zero is returned for empty string.
+func parseScheme(s string) (proto.Scheme, error) {
+	switch len(s) {
+	case 0:
+		return 0, nil
+	case 1:
+		return s[0], nil
+	default:
+		b, err := strconv.ParseUint(s, 0, 8)
+		if err != nil || b == 0 {
+			return 0, errors.New("expected a character or a non-zero byte value")
+		}
+		return byte(b), nil
+	}
+}
+
+func newClient(url, apiKey string, doer client.Doer) *client.Client {
 	opts := client.Options{
 		BaseUrl: url,
-		Client:  &http.Client{Timeout: timeout},
+		Client:  doer,
+		ApiKey:  apiKey,
 	}
 	// The error can be safely ignored because `NewClient` function only checks the number of passed `opts`
 	cl, _ := client.NewClient(opts)
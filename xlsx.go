@@ -0,0 +1,120 @@
+package main
+
+import (
+	"io"
+	"time"
+
+	"github.com/alexeykiselev/waves-block-complexity/pkg/complexity"
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	xlsxTransactionsSheet = "Transactions"
+	xlsxBlocksSheet       = "Blocks"
+	xlsxStatisticsSheet   = "Statistics"
+)
+
+// xlsxPrinter collects results and writes them on flush as an Excel workbook with sheets of transactions, summaries
+// of blocks and aggregated statistics of the range.
+type xlsxPrinter struct {
+	w      io.Writer
+	blocks []complexity.BlockComplexity
+	st     *complexity.RangeStats
+}
+
+func (p *xlsxPrinter) block(b complexity.BlockComplexity) error {
+	p.blocks = append(p.blocks, b)
+	return nil
+}
+
+func (p *xlsxPrinter) summary(b complexity.BlockComplexity) error {
+	p.blocks = append(p.blocks, b)
+	return nil
+}
+
+func (p *xlsxPrinter) stats(s complexity.RangeStats) error {
+	p.st = &s
+	return nil
+}
+
+func (p *xlsxPrinter) flush() error {
+	f := excelize.NewFile()
+	defer f.Close()
+	// The default sheet of a new workbook is renamed, so the workbook has no empty sheets
+	if err := f.SetSheetName(f.GetSheetName(0), xlsxTransactionsSheet); err != nil {
+		return err
+	}
+	txs := [][]interface{}{{"Block", "Height", "Transaction", "Type", "Sender", "Status", "Complexity", "Fee", "Fee Asset", "dApp"}}
+	blocks := [][]interface{}{{"Height", "Block", "Time", "Generator", "Transactions", "Complexity", "Failed Complexity", "Limit", "Utilization, %", "Size", "Base Target", "Fees, wavelets"}}
+	for _, b := range p.blocks {
+		for _, c := range b.Transactions {
+			dApp := ""
+			if c.Invocation != nil {
+				dApp = c.Invocation.DApp
+			}
+			var sc interface{} = c.SpentComplexity
+			if c.Unknown {
+				sc = nil // The cell of unknown complexity is left empty
+			}
+			txs = append(txs, []interface{}{b.ID.String(), b.Height, c.ID.String(), complexity.TransactionTypeName(c.Type),
+				c.Sender.String(), c.ApplicationStatus, sc, c.Fee, c.FeeAssetID, dApp})
+		}
+		blocks = append(blocks, []interface{}{b.Height, b.ID.String(), time.UnixMilli(int64(b.Timestamp)).UTC(),
+			b.Generator.String(), len(b.Transactions), b.Complexity, b.FailedComplexity, b.Limit, b.Utilization, b.Size, b.BaseTarget, b.Fees})
+	}
+	if err := xlsxSheet(f, xlsxTransactionsSheet, txs); err != nil {
+		return err
+	}
+	if err := xlsxSheet(f, xlsxBlocksSheet, blocks); err != nil {
+		return err
+	}
+	if s := p.st; s != nil {
+		rows := [][]interface{}{
+			{"Metric", "Value"},
+			{"Blocks", s.Blocks},
+			{"Transactions", s.Transactions},
+			{"Total Complexity", s.Complexity},
+			{"Succeeded Transactions Complexity", s.Complexity - s.FailedComplexity},
+			{"Failed Transactions Complexity", s.FailedComplexity},
+			{"Failed Transactions", s.FailedTransactions},
+			{"Average Block Complexity", s.AverageComplexity},
+			{"Max Block Complexity", s.MaxComplexity},
+			{"Height of Max Block Complexity", s.MaxHeight},
+			{"Average Utilization, %", s.AverageUtilization},
+			{"Max Utilization, %", s.MaxUtilization},
+			{"Median Block Complexity", s.Distribution.Median},
+			{"P95 Block Complexity", s.Distribution.P95},
+			{},
+			{"Type", "Transactions", "Complexity"},
+		}
+		for _, t := range s.Types {
+			rows = append(rows, []interface{}{complexity.TransactionTypeName(t.Type), t.Transactions, t.Complexity})
+		}
+		if err := xlsxSheet(f, xlsxStatisticsSheet, rows); err != nil {
+			return err
+		}
+	}
+	_, err := f.WriteTo(p.w)
+	return err
+}
+
+func (p *xlsxPrinter) streaming() bool {
+	return false
+}
+
+// xlsxSheet fills the sheet with the rows, creating the sheet if it doesn't exist. The first row is the header.
+func xlsxSheet(f *excelize.File, name string, rows [][]interface{}) error {
+	if _, err := f.NewSheet(name); err != nil {
+		return err
+	}
+	for i, r := range rows {
+		cell, err := excelize.CoordinatesToCellName(1, i+1)
+		if err != nil {
+			return err
+		}
+		if err := f.SetSheetRow(name, cell, &r); err != nil {
+			return err
+		}
+	}
+	return f.SetPanes(name, &excelize.Panes{Freeze: true, YSplit: 1, TopLeftCell: "A2", ActivePane: "bottomLeft"})
+}